@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/alecthomas/kong"
 	"github.com/crossplane-contrib/terraform-provider-gen/internal/template"
 	"github.com/spf13/afero"
@@ -10,15 +12,36 @@ var CompileTemplate struct {
 	Root        string `help:"path to terraform-provider-gen repository root (default CWD)."`
 	Output      string `help:"Compiled templates will be written to this directory."`
 	PackageRoot string `help:"Go package that compiled templates will exist within."`
+	Check       bool   `help:"Fail if the compiled templates are not up to date with the templates, without writing anything."`
 }
 
 func main() {
 	ctx := kong.Parse(&CompileTemplate)
+	if CompileTemplate.Check {
+		ctx.FatalIfErrorf(checkTemplates(CompileTemplate.Root, CompileTemplate.Output, CompileTemplate.PackageRoot))
+		return
+	}
 	ctx.FatalIfErrorf(compileTemplates(CompileTemplate.Root, CompileTemplate.Output, CompileTemplate.PackageRoot))
 }
 
+const templateDir = "hack/template"
+
 func compileTemplates(root, output, packageRoot string) error {
-	templateDir := "hack/template"
 	tc := template.NewTemplateCompiler(afero.NewOsFs(), root, templateDir, output, packageRoot)
 	return tc.CompileGeneratedTemplates()
 }
+
+func checkTemplates(root, output, packageRoot string) error {
+	tc := template.NewTemplateCompiler(afero.NewOsFs(), root, templateDir, output, packageRoot)
+	outdated, err := tc.Check()
+	if err != nil {
+		return err
+	}
+	for _, p := range outdated {
+		fmt.Printf("%s is out of date\n", p)
+	}
+	if len(outdated) > 0 {
+		return fmt.Errorf("%d compiled templates are out of date with %s, run template-compile to update them", len(outdated), templateDir)
+	}
+	return nil
+}
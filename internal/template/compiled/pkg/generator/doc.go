@@ -1,5 +1,5 @@
 package generator
 
 func Doc() string {
-	return "/*\n\tCopyright 2019 The Crossplane Authors.\n\n\tLicensed under the Apache License, Version 2.0 (the \"License\");\n\tyou may not use this file except in compliance with the License.\n\tYou may obtain a copy of the License at\n\n\t    http://www.apache.org/licenses/LICENSE-2.0\n\n\tUnless required by applicable law or agreed to in writing, software\n\tdistributed under the License is distributed on an \"AS IS\" BASIS,\n\tWITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.\n\tSee the License for the specific language governing permissions and\n\tlimitations under the License.\n*/\n\npackage {{ .KubernetesVersion}}\n\n// +kubebuilder:object:generate=true\n// +kubebuilder:validation:Optional\n// +groupName={{ .APIGroup }}\n// +versionName={{ .KubernetesVersion }}\n"
+	return "/*\n\tCopyright 2019 The Crossplane Authors.\n\n\tLicensed under the Apache License, Version 2.0 (the \"License\");\n\tyou may not use this file except in compliance with the License.\n\tYou may obtain a copy of the License at\n\n\t    http://www.apache.org/licenses/LICENSE-2.0\n\n\tUnless required by applicable law or agreed to in writing, software\n\tdistributed under the License is distributed on an \"AS IS\" BASIS,\n\tWITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.\n\tSee the License for the specific language governing permissions and\n\tlimitations under the License.\n*/\n\n// Package {{ .KubernetesVersion}} holds the generated {{ .ManagedResourceName }} managed resource.\n//\n// The generated encoder, decoder and merger call optional hooks, which are\n// methods implemented in a hand-written file of this package, eg hooks.go:\n//\n//\tfunc (e *ctyEncoder) PostEncode(r *{{ .ManagedResourceName }}, v cty.Value) (cty.Value, error)\n//\tfunc (e *ctyDecoder) PostDecode(r *{{ .ManagedResourceName }}, v cty.Value) error\n//\tfunc (r *resourceMerger) PostMerge(kube, prov *{{ .ManagedResourceName }}, md *plugin.MergeDescription)\n//\n// Unlike an overlay of a generated file, a hook keeps working as the package\n// is regenerated, and hand-written files are kept by generate --prune.\npackage {{ .KubernetesVersion}}\n\n// +kubebuilder:object:generate=true\n// +kubebuilder:validation:Optional\n// +groupName={{ .APIGroup }}\n// +versionName={{ .KubernetesVersion }}\n"
 }
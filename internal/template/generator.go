@@ -1,13 +1,19 @@
 package template
 
 import (
-	"github.com/dave/jennifer/jen"
-	"github.com/iancoleman/strcase"
-	"github.com/spf13/afero"
+	"bytes"
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	tmpl "text/template"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/template/funcs"
+	"github.com/dave/jennifer/jen"
+	"github.com/iancoleman/strcase"
+	"github.com/spf13/afero"
 )
 
 func (tc *TemplateCompiler) Walk(path string, info os.FileInfo, err error) error {
@@ -56,11 +62,18 @@ func (de DispatchEntry) dispatchMapEntry() (jen.Code, jen.Code) {
 	return key, val
 }
 
+// CompileGeneratedTemplates parses every template, then writes each as a
+// function returning its source, along with the map dispatching template
+// paths to those functions. Nothing is written unless every template parses.
 func (tc *TemplateCompiler) CompileGeneratedTemplates() error {
+	tc.sourcePaths = make([]string, 0)
 	err := afero.Walk(tc.fs, filepath.Join(tc.root, tc.templateDir), tc.Walk)
 	if err != nil {
 		return err
 	}
+	if err := tc.ParseTemplates(); err != nil {
+		return err
+	}
 	dispatchers := make([]DispatchEntry, 0)
 	for _, source := range tc.sourcePaths {
 		t := NewTemplateRenderer(source, tc.root, tc.templateDir, tc.output, tc.packageRoot, tc.fs)
@@ -73,6 +86,84 @@ func (tc *TemplateCompiler) CompileGeneratedTemplates() error {
 	return tc.renderDispatchMap(dispatchers)
 }
 
+// ParseTemplates parses every template found by Walk with the functions
+// available to templates at generation time, reporting the file and line of
+// each syntax error.
+func (tc *TemplateCompiler) ParseTemplates() error {
+	// errors are collected as strings, since pkg/generator depends on the compiled templates
+	failed := make([]string, 0)
+	for _, source := range tc.sourcePaths {
+		b, err := afero.ReadFile(tc.fs, source)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(tc.root, source)
+		if err != nil {
+			name = source
+		}
+		if _, err := tmpl.New(name).Funcs(funcs.FuncMap()).Parse(string(b)); err != nil {
+			// errors read "template: <name>:<line>: <message>"
+			failed = append(failed, strings.TrimPrefix(err.Error(), "template: "))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("templates failed to parse:\n - %s", strings.Join(failed, "\n - "))
+	}
+	return nil
+}
+
+// Check compiles the templates in memory and compares the result with the
+// output directory, returning in path order each compiled file which is
+// missing, differs from its template, or has no template. Nothing is written.
+func (tc *TemplateCompiler) Check() ([]string, error) {
+	compiled := afero.NewMemMapFs()
+	fs := afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(tc.fs), compiled)
+	if err := NewTemplateCompiler(fs, tc.root, tc.templateDir, tc.output, tc.packageRoot).CompileGeneratedTemplates(); err != nil {
+		return nil, err
+	}
+	outdated := make([]string, 0)
+	expected := make(map[string]bool)
+	err := afero.Walk(compiled, tc.output, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		expected[p] = true
+		want, err := afero.ReadFile(compiled, p)
+		if err != nil {
+			return err
+		}
+		have, err := afero.ReadFile(tc.fs, p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				outdated = append(outdated, p)
+				return nil
+			}
+			return err
+		}
+		if !bytes.Equal(want, have) {
+			outdated = append(outdated, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = afero.Walk(tc.fs, tc.output, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if !expected[p] && strings.HasSuffix(p, ".go") {
+			outdated = append(outdated, p)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	sort.Strings(outdated)
+	return outdated, nil
+}
+
 func (de DispatchEntry) importPath() string {
 	return path.Join(de.PackageRoot, de.RelativeImport)
 }
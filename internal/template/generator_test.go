@@ -0,0 +1,106 @@
+package template
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+const testPackageRoot = "github.com/crossplane-contrib/terraform-provider-gen/internal/template/compiled"
+
+func testTemplateFs(t *testing.T, templates map[string]string) afero.Fs {
+	fs := afero.NewMemMapFs()
+	for p, content := range templates {
+		if err := afero.WriteFile(fs, "/repo/hack/template/"+p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fs
+}
+
+func TestCheck(t *testing.T) {
+	fs := testTemplateFs(t, map[string]string{
+		"provider/main.go.tpl":      "package {{ .Name }}\n",
+		"pkg/generator/doc.go.tmpl": "// {{ plural .ManagedResourceName }}\n",
+	})
+	tc := NewTemplateCompiler(fs, "/repo", "hack/template", "/repo/compiled", testPackageRoot)
+	outdated, err := tc.Check()
+	if err != nil {
+		t.Fatalf("Unexpected error from Check: %s", err)
+	}
+	expected := []string{"/repo/compiled/dispatch.go", "/repo/compiled/pkg/generator/doc.go", "/repo/compiled/provider/main.go"}
+	if !reflect.DeepEqual(outdated, expected) {
+		t.Errorf("Expected every file to be out of date before compiling, saw %v", outdated)
+	}
+	if _, err := fs.Stat("/repo/compiled"); err == nil {
+		t.Errorf("Expected Check not to write anything")
+	}
+
+	if err := tc.CompileGeneratedTemplates(); err != nil {
+		t.Fatalf("Unexpected error from CompileGeneratedTemplates: %s", err)
+	}
+	outdated, err = tc.Check()
+	if err != nil {
+		t.Fatalf("Unexpected error from Check: %s", err)
+	}
+	if len(outdated) != 0 {
+		t.Errorf("Expected compiled templates to be up to date, saw %v", outdated)
+	}
+
+	if err := afero.WriteFile(fs, "/repo/hack/template/provider/main.go.tpl", []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(fs, "/repo/compiled/provider/removed.go", []byte("package provider\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outdated, err = tc.Check()
+	if err != nil {
+		t.Fatalf("Unexpected error from Check: %s", err)
+	}
+	expected = []string{"/repo/compiled/provider/main.go", "/repo/compiled/provider/removed.go"}
+	if !reflect.DeepEqual(outdated, expected) {
+		t.Errorf("Expected a changed template and a compiled file without a template, saw %v", outdated)
+	}
+}
+
+func TestCompileReportsParseErrors(t *testing.T) {
+	fs := testTemplateFs(t, map[string]string{
+		"provider/main.go.tpl":  "package main\n\n{{ if .Name }}\n",
+		"provider/index.go.tpl": "package {{ .Name }}\n// {{ unknownFunc .Name }}\n",
+		"provider/doc.go.tpl":   "package {{ .Name }}\n",
+	})
+	tc := NewTemplateCompiler(fs, "/repo", "hack/template", "/repo/compiled", testPackageRoot)
+	err := tc.CompileGeneratedTemplates()
+	if err == nil {
+		t.Fatalf("Expected an error compiling templates which do not parse")
+	}
+	for _, expected := range []string{
+		"hack/template/provider/main.go.tpl:4: unexpected EOF",
+		`hack/template/provider/index.go.tpl:2: function "unknownFunc" not defined`,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, saw:\n%s", expected, err)
+		}
+	}
+	if strings.Contains(err.Error(), "doc.go.tpl") {
+		t.Errorf("Expected no error for a template which parses, saw:\n%s", err)
+	}
+	if _, err := fs.Stat("/repo/compiled"); err == nil {
+		t.Errorf("Expected nothing to be written when a template does not parse")
+	}
+}
+
+// TestCompiledTemplatesUpToDate fails when hack/template is changed without
+// running template-compile
+func TestCompiledTemplatesUpToDate(t *testing.T) {
+	tc := NewTemplateCompiler(afero.NewOsFs(), "../..", "hack/template", "../../internal/template/compiled", testPackageRoot)
+	outdated, err := tc.Check()
+	if err != nil {
+		t.Fatalf("Unexpected error from Check: %s", err)
+	}
+	if len(outdated) > 0 {
+		t.Errorf("Compiled templates are out of date, run template-compile: %v", outdated)
+	}
+}
@@ -9,9 +9,11 @@ import (
 	"gopkg.in/alecthomas/kingpin.v2"
 
 	"github.com/crossplane-contrib/terraform-provider-gen/pkg/integration"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/pipeline"
 	"github.com/crossplane-contrib/terraform-provider-gen/pkg/provider"
 	"github.com/crossplane-contrib/terraform-provider-gen/pkg/template"
 	"github.com/crossplane-contrib/terraform-runtime/pkg/client"
+	"github.com/spf13/afero"
 )
 
 var (
@@ -25,12 +27,40 @@ var (
 	providerName = gen.Flag("providerName", "Terraform provider name. must match the value given to the 'provider' directive in a terraform config.").String()
 
 	outputDir       = generateCmd.Flag("output-dir", "output path").String()
-	overlayBasePath = generateCmd.Flag("overlay-dir", "Path to search for files to overlay instead of generated code. Nesting mirrors output tree.").String()
+	overlayBasePath = generateCmd.Flag("overlay-dir", "Path to search for files to overlay instead of generated code. Nesting mirrors output tree. <file>.txt replaces a file, <file>.patch is a unified diff and <file>.decls.txt holds Go declarations applied to the generated file.").String()
+	providerOverlay = generateCmd.Flag("provider-overlay-dir", "Path to search for files to overlay instead of generated provider files such as cmd/provider/main.go. Nesting mirrors the provider base-path.").String()
 	cfgPath         = generateCmd.Flag("cfg-path", "path to schema generation config yaml").String()
+	jobs            = generateCmd.Flag("jobs", "Number of resources to generate concurrently, 0 uses one worker per CPU. Output does not depend on this setting.").Default("0").Int()
+	dryRun          = generateCmd.Flag("dry-run", "Render everything in memory and report the files that would change, without writing to disk.").Bool()
+	showDiff        = generateCmd.Flag("diff", "With --dry-run, print a unified diff of each file that would change instead of a list of files.").Bool()
+	prune           = generateCmd.Flag("prune", "Remove the generated files of resource packages for resources which are no longer generated, keeping any hand-written files. Without this flag they are only reported.").Bool()
+	verifyTypes     = generateCmd.Flag("verify", "Type check each generated resource package against stub dependencies, failing before anything is written.").Bool()
+	templateDirs    = generateCmd.Flag("template-dir", "Directory of templates used in place of the built-in templates at the same path, eg provider/cmd/provider/main.go.tpl. Can be repeated, earlier directories take precedence.").Strings()
+	cfgOverrides    = generateCmd.Flag("set", "Override a config key with a yaml value, eg --set base-path=/tmp/provider. Nested keys are separated by dots. Can be repeated.").Strings()
+	fromIR          = generateCmd.Flag("from-ir", "Directory of <resource>.yaml or <resource>.json files, as written by translate ir dump, used in place of translating the schema of those resources.").String()
 
 	bootStrapCmd       = generateCmd.Command("bootstrap", "bootstrap a new provider")
 	generateTypesCmd   = generateCmd.Command("types", "Use Provider.GetSchema() to generate crossplane types.")
 	generateRuntimeCmd = generateCmd.Command("runtime", "Generate terraform-runtime methods for generated crossplane types.")
+	generateAllCmd     = generateCmd.Command("all", "Bootstrap the provider and generate types and runtime methods, loading the provider schema only once.")
+
+	configCmd          = gen.Command("config", "provider config subcommands")
+	configValidateCmd  = configCmd.Command("validate", "Validate a config file, checking resource names against the provider schema.")
+	configValidatePath = configValidateCmd.Flag("cfg-path", "path to schema generation config yaml").Required().String()
+	configValidateSet  = configValidateCmd.Flag("set", "Override a config key with a yaml value. Can be repeated.").Strings()
+
+	translateCmd   = gen.Command("translate", "inspect how the provider schema is translated")
+	irCmd          = translateCmd.Command("ir", "intermediate representation subcommands")
+	irDumpCmd      = irCmd.Command("dump", "Print the ManagedResource rendered for a resource, after field overrides and optimizers are applied.")
+	irDumpResource = irDumpCmd.Arg("resource", "terraform name of the resource, eg aws_s3_bucket").Required().String()
+	irDumpCfgPath  = irDumpCmd.Flag("cfg-path", "path to schema generation config yaml").Required().String()
+	irDumpFormat   = irDumpCmd.Flag("format", "Output format: json or yaml").Default("yaml").Enum("json", "yaml")
+	irDumpSet      = irDumpCmd.Flag("set", "Override a config key with a yaml value. Can be repeated.").Strings()
+
+	listResourcesCmd     = gen.Command("list-resources", "Show which resources in the provider schema are selected by the include/exclude rules of a config.")
+	listResourcesCfgPath = listResourcesCmd.Flag("cfg-path", "path to schema generation config yaml").Required().String()
+	listResourcesShow    = listResourcesCmd.Flag("show", "Which resources to list: all, included or excluded").Default("all").Enum("all", "included", "excluded")
+	listResourcesSet     = listResourcesCmd.Flag("set", "Override a config key with a yaml value. Can be repeated.").Strings()
 
 	analyzeCmd       = gen.Command("analyze", "perform analysis on a provider's schemas")
 	nestingCmd       = analyzeCmd.Command("nesting", "report on the different nesting paths and modes observed in a provider")
@@ -50,18 +80,11 @@ func run() error {
 	cmd := kingpin.MustParse(gen.Parse(os.Args[1:]))
 	switch cmd {
 	case bootStrapCmd.FullCommand():
-		cfg, err := provider.ConfigFromFile(*cfgPath)
-		if err != nil {
-			return err
-		}
-		tg := template.NewCompiledTemplateGetter()
-		p, err := client.NewGRPCProvider(cfg.Name, *pluginPath)
+		p, err := newPipeline(*cfgPath, *cfgOverrides)
 		if err != nil {
 			return err
 		}
-		schema := p.GetSchema()
-		bs := provider.NewBootstrapper(cfg, tg, schema)
-		return bs.Bootstrap()
+		return p.Bootstrap()
 	case updateFixturesCmd.FullCommand():
 		opts := []integration.TestConfigOption{
 			integration.WithPluginPath(*pluginPath),
@@ -75,30 +98,63 @@ func run() error {
 		if err != nil {
 			return err
 		}
-	case generateTypesCmd.FullCommand(), generateRuntimeCmd.FullCommand():
-		cfg, err := provider.ConfigFromFile(*cfgPath)
+	case generateTypesCmd.FullCommand():
+		p, err := newPipeline(*cfgPath, *cfgOverrides)
 		if err != nil {
 			return err
 		}
-		if len(cfg.ExcludeResources) > 0 {
-			fmt.Println("Excluding the following resources from codegen:")
-			for _, p := range cfg.ExcludeResources {
-				fmt.Println(p)
-			}
+		return p.Types()
+	case generateRuntimeCmd.FullCommand():
+		p, err := newPipeline(*cfgPath, *cfgOverrides)
+		if err != nil {
+			return err
 		}
-
-		tg := template.NewCompiledTemplateGetter()
-		p, err := client.NewGRPCProvider(cfg.Name, *pluginPath)
+		return p.Runtime()
+	case generateAllCmd.FullCommand():
+		p, err := newPipeline(*cfgPath, *cfgOverrides)
 		if err != nil {
 			return err
 		}
-		st := provider.NewSchemaTranslator(cfg, *outputDir, *overlayBasePath, p.GetSchema(), tg)
-
-		switch cmd {
-		case generateTypesCmd.FullCommand():
-			return st.WriteGeneratedTypes()
-		case generateRuntimeCmd.FullCommand():
-			return st.WriteGeneratedRuntime()
+		return p.All()
+	case configValidateCmd.FullCommand():
+		p, err := newPipeline(*configValidatePath, *configValidateSet)
+		if err != nil {
+			return err
+		}
+		err = p.ValidateConfig()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s is valid\n", *configValidatePath)
+	case irDumpCmd.FullCommand():
+		p, err := newPipeline(*irDumpCfgPath, *irDumpSet)
+		if err != nil {
+			return err
+		}
+		b, err := p.ManagedResourceIR(*irDumpResource, *irDumpFormat)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(b)
+		return err
+	case listResourcesCmd.FullCommand():
+		p, err := newPipeline(*listResourcesCfgPath, *listResourcesSet)
+		if err != nil {
+			return err
+		}
+		selections, err := p.SelectResources()
+		if err != nil {
+			return err
+		}
+		for _, rs := range selections {
+			status := "included"
+			if !rs.Included {
+				status = "excluded"
+			}
+			if *listResourcesShow != "all" && *listResourcesShow != status {
+				continue
+			}
+			fmt.Printf("%s\t%s\t%s\n", rs.Name, status, rs.Reason())
 		}
 	case nestingCmd.FullCommand():
 		p, err := client.NewGRPCProvider(*providerName, *pluginPath)
@@ -125,7 +181,7 @@ func run() error {
 				fmt.Printf("%s: %s (%d, %d, %t)\n", k, b.Mode, b.MinItems, b.MaxItems, b.IsRequired)
 			}
 		default:
-			return fmt.Errorf("report-style=%s not recognized", nestingCmdStyle)
+			return fmt.Errorf("report-style=%s not recognized", *nestingCmdStyle)
 		}
 	case flatCmd.FullCommand():
 		p, err := client.NewGRPCProvider(*providerName, *pluginPath)
@@ -167,6 +223,35 @@ func run() error {
 	return nil
 }
 
+// newPipeline loads the config at cfgPath and sets up a pipeline writing to
+// the OS filesystem according to the generate flags.
+func newPipeline(cfgPath string, overrides []string) (*pipeline.Pipeline, error) {
+	if *showDiff && !*dryRun {
+		return nil, fmt.Errorf("--diff can only be used with --dry-run")
+	}
+	cfg, err := provider.ConfigFromFile(cfgPath, overrides...)
+	if err != nil {
+		return nil, err
+	}
+	opts := pipeline.Options{
+		OutputDir:          *outputDir,
+		OverlayDir:         *overlayBasePath,
+		ProviderOverlayDir: *providerOverlay,
+		Jobs:               *jobs,
+		Writer:             provider.WriterOptions{DryRun: *dryRun, Diff: *showDiff},
+		Prune:              *prune,
+		Verify:             *verifyTypes,
+		IRDir:              *fromIR,
+	}
+	fs := afero.NewOsFs()
+	tg, err := template.NewUserTemplateGetter(fs, *templateDirs...)
+	if err != nil {
+		return nil, err
+	}
+	source := pipeline.PluginSchema(cfg.Name, *pluginPath)
+	return pipeline.New(source, cfg, tg, fs, opts), nil
+}
+
 type filterFunc func(t string) bool
 
 func skipTypeFunc(incTypes, exclTypes string) (filterFunc, error) {
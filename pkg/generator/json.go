@@ -0,0 +1,45 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MarshalText encodes a FieldType by name without its prefix, eg Struct
+func (i FieldType) MarshalText() ([]byte, error) {
+	if i < 0 || i >= FieldType(len(_FieldType_index)-1) {
+		return nil, fmt.Errorf("invalid FieldType %d", int(i))
+	}
+	return []byte(strings.TrimPrefix(i.String(), "FieldType")), nil
+}
+
+// UnmarshalText decodes a FieldType encoded by MarshalText
+func (i *FieldType) UnmarshalText(text []byte) error {
+	for t := FieldType(0); t < FieldType(len(_FieldType_index)-1); t++ {
+		if "FieldType"+string(text) == t.String() {
+			*i = t
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown FieldType %q", string(text))
+}
+
+// MarshalText encodes an AttributeType by name without its prefix, eg String
+// or MapStringKey
+func (i AttributeType) MarshalText() ([]byte, error) {
+	if i < 0 || i >= AttributeType(len(_AttributeType_index)-1) {
+		return nil, fmt.Errorf("invalid AttributeType %d", int(i))
+	}
+	return []byte(strings.TrimPrefix(i.String(), "AttributeType")), nil
+}
+
+// UnmarshalText decodes an AttributeType encoded by MarshalText
+func (i *AttributeType) UnmarshalText(text []byte) error {
+	for t := AttributeType(0); t < AttributeType(len(_AttributeType_index)-1); t++ {
+		if "AttributeType"+string(text) == t.String() {
+			*i = t
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown AttributeType %q", string(text))
+}
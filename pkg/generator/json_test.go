@@ -0,0 +1,46 @@
+package generator
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestManagedResourceJSON(t *testing.T) {
+	mr := NewManagedResource(FakeResourceName, FakePackagePath)
+	mr.Parameters = Field{
+		Name: "ForProvider",
+		Type: FieldTypeStruct,
+		Fields: []Field{
+			{
+				Name:           "Tags",
+				Type:           FieldTypeAttribute,
+				AttributeField: AttributeField{Type: AttributeTypeMapStringKey, MapValueType: AttributeTypeString},
+				Tag:            &StructTag{Json: &StructTagJson{Name: "tags", Omitempty: true}},
+				Optional:       true,
+			},
+		},
+	}
+	b, err := json.Marshal(mr)
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling ManagedResource: %s", err)
+	}
+	for _, expected := range []string{`"type":"Struct"`, `"type":"MapStringKey","mapValueType":"String"`} {
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("Expected %s in %s", expected, string(b))
+		}
+	}
+	decoded := &ManagedResource{}
+	if err := json.Unmarshal(b, decoded); err != nil {
+		t.Fatalf("Unexpected error unmarshaling ManagedResource: %s", err)
+	}
+	if !reflect.DeepEqual(decoded.Parameters, mr.Parameters) {
+		t.Errorf("Expected Parameters to survive a round trip, saw %v", decoded.Parameters)
+	}
+
+	var ft FieldType
+	if err := json.Unmarshal([]byte(`"Blob"`), &ft); err == nil {
+		t.Errorf("Expected an error decoding an unknown FieldType")
+	}
+}
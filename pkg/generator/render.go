@@ -11,6 +11,30 @@ import (
 const CommentBlankLine = "" // assumes comments are joined with newlines
 const KubebuilderObjectRoot = "+kubebuilder:object:root=true"
 const KubebuilderMarkStatusSubresource = "+kubebuilder:subresource:status"
+const KubebuilderMarkImmutable = "+immutable"
+
+// DefaultPrinterColumns returns the columns every crossplane managed resource
+// displays in kubectl get output. Additional columns should be inserted
+// before the final AGE column.
+func DefaultPrinterColumns() []PrinterColumn {
+	return []PrinterColumn{
+		{Name: "READY", Type: "string", JSONPath: ".status.conditions[?(@.type=='Ready')].status"},
+		{Name: "SYNCED", Type: "string", JSONPath: ".status.conditions[?(@.type=='Synced')].status"},
+		{Name: "EXTERNAL-NAME", Type: "string", JSONPath: `.metadata.annotations.crossplane\.io/external-name`},
+		{Name: "AGE", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+	}
+}
+
+// RenderKubebuilderPrintColumnAnnotation renders a single kubebuilder
+// printcolumn tag. The JSONPath is quoted so that escaped dots, as seen in
+// annotation keys, survive the trip through controller-gen.
+func RenderKubebuilderPrintColumnAnnotation(pc PrinterColumn) string {
+	annotation := fmt.Sprintf("+kubebuilder:printcolumn:name=%q,type=%q,JSONPath=%q", pc.Name, pc.Type, pc.JSONPath)
+	if pc.Priority > 0 {
+		annotation = fmt.Sprintf("%s,priority=%d", annotation, pc.Priority)
+	}
+	return annotation
+}
 
 // RenderKubebuilderResourceAnnotation renderes the kubebuilder resource tag
 // which indicates whether the resources is namespace- or cluster-scoped
@@ -19,9 +43,9 @@ const KubebuilderMarkStatusSubresource = "+kubebuilder:subresource:status"
 func RenderKubebuilderResourceAnnotation(mr *ManagedResource) string {
 	catCSV := mr.CategoryCSV()
 	if catCSV == "" {
-		return "+kubebuilder:resource:scope=Cluster"
+		return fmt.Sprintf("+kubebuilder:resource:scope=%s", mr.ResourceScope())
 	}
-	return fmt.Sprintf(" +kubebuilder:resource:scope=Cluster,categories={%s}", catCSV)
+	return fmt.Sprintf("+kubebuilder:resource:scope=%s,categories={%s}", mr.ResourceScope(), catCSV)
 }
 
 func ResourceTypeFragment(mr *ManagedResource) *Fragment {
@@ -39,11 +63,15 @@ func ResourceTypeFragment(mr *ManagedResource) *Fragment {
 		CommentBlankLine,
 		// TODO: check if there is a reasonable description field we can use for this comment
 		fmt.Sprintf("%s is a managed resource representing a resource mirrored in the cloud", namer.TypeName()),
-		// TODO: handle printcolumn lines
-		// we always mark ou resources
+	}
+	for _, pc := range mr.PrinterColumns {
+		comments = append(comments, RenderKubebuilderPrintColumnAnnotation(pc))
+	}
+	// we always mark ou resources
+	comments = append(comments,
 		KubebuilderMarkStatusSubresource,
 		RenderKubebuilderResourceAnnotation(mr),
-	}
+	)
 
 	return &Fragment{
 		comments:  comments,
@@ -131,6 +159,9 @@ func FieldFragments(f Field) []*Fragment {
 		if attrStatement == nil {
 			continue
 		}
+		if a.Immutable {
+			attributes = append(attributes, j.Comment(KubebuilderMarkImmutable))
+		}
 		attributes = append(attributes, attrStatement)
 		if a.Type == FieldTypeStruct {
 			for _, frag := range FieldFragments(a) {
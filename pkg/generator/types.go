@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
 )
 
 type FieldType int
@@ -42,55 +43,98 @@ const (
 	AttributeTypeMapStringKey
 )
 
+// ResourceScope values are used in the kubebuilder resource annotation to
+// indicate whether a managed resource is cluster- or namespace-scoped
+const (
+	ResourceScopeCluster    = "Cluster"
+	ResourceScopeNamespaced = "Namespaced"
+)
+
 var InvalidMRNameEmpty error = errors.New(".Name is required")
 var InvalidMRPackagePathEmpty error = errors.New(".PackagePath is required")
+var InvalidMRScope error = errors.New(".Scope must be Cluster or Namespaced")
 
 type StructTagJson struct {
-	Name      string
-	Omitempty bool
-	Inline    bool
+	Name      string `json:"name"`
+	Omitempty bool   `json:"omitempty,omitempty"`
+	Inline    bool   `json:"inline,omitempty"`
 }
 
 type StructTag struct {
-	Json *StructTagJson
+	Json *StructTagJson `json:"json,omitempty"`
 }
 
+// Field is a field of a generated struct. Fields, along with the
+// ManagedResource holding them, can be serialized as JSON, except for the
+// encode, decode and merge function generators, which are left out.
 type Field struct {
-	Name              string
-	Type              FieldType
-	Fields            []Field
-	StructField       StructField
-	AttributeField    AttributeField
-	IsSlice           bool
-	Tag               *StructTag
-	EncodeFnGenerator EncodeFnGenerator
-	DecodeFnGenerator DecodeFnGenerator
-	MergeFnGenerator  MergeFnGenerator
+	Name              string            `json:"name"`
+	Type              FieldType         `json:"type"`
+	Fields            []Field           `json:"fields,omitempty"`
+	StructField       StructField       `json:"structField"`
+	AttributeField    AttributeField    `json:"attributeField"`
+	IsSlice           bool              `json:"isSlice,omitempty"`
+	Tag               *StructTag        `json:"tag,omitempty"`
+	EncodeFnGenerator EncodeFnGenerator `json:"-"`
+	DecodeFnGenerator DecodeFnGenerator `json:"-"`
+	MergeFnGenerator  MergeFnGenerator  `json:"-"`
 
 	// struct comment "annotations"
-	Computed  bool
-	Optional  bool
-	Required  bool
-	Sensitive bool
+	Computed  bool `json:"computed,omitempty"`
+	Optional  bool `json:"optional,omitempty"`
+	Required  bool `json:"required,omitempty"`
+	Sensitive bool `json:"sensitive,omitempty"`
+	// Immutable fields are rendered with a +immutable comment marker
+	Immutable bool `json:"immutable,omitempty"`
+
+	// Schema records the terraform attribute or block the field was
+	// translated from, so its function generators can be restored
+	Schema *FieldSchema `json:"schema,omitempty"`
+}
+
+// FieldSchema describes the terraform attribute or block a Field was
+// translated from. Unlike the encode, decode and merge function generators
+// it is serialized with the Field, so that they can be restored when a
+// ManagedResource is loaded, see translate.RestoreGenerators.
+type FieldSchema struct {
+	// Name is the name of the attribute or block in the terraform schema
+	Name string `json:"name"`
+	// Type is the cty type of an attribute
+	Type *cty.Type `json:"type,omitempty"`
+	// Nesting is the nesting mode of a block: single, group, list, set or map
+	Nesting string `json:"nesting,omitempty"`
 }
 
 type StructField struct {
-	PackagePath string
-	TypeName    string
+	PackagePath string `json:"packagePath,omitempty"`
+	TypeName    string `json:"typeName,omitempty"`
 }
 
 type AttributeField struct {
-	Type         AttributeType
-	MapValueType AttributeType
+	Type         AttributeType `json:"type"`
+	MapValueType AttributeType `json:"mapValueType,omitempty"`
+}
+
+// PrinterColumn describes an additional column shown by kubectl get,
+// rendered as a +kubebuilder:printcolumn comment annotation
+type PrinterColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	JSONPath string `json:"jsonPath"`
+	Priority int    `json:"priority,omitempty"`
 }
 
 type ManagedResource struct {
-	Name         string
-	PackagePath  string
-	Parameters   Field
-	Observation  Field
-	namer        ResourceNamer
-	CategoryTags []string
+	Name           string `json:"name"`
+	PackagePath    string `json:"packagePath"`
+	Parameters     Field  `json:"parameters"`
+	Observation    Field  `json:"observation"`
+	namer          ResourceNamer
+	CategoryTags   []string        `json:"categoryTags,omitempty"`
+	PrinterColumns []PrinterColumn `json:"printerColumns,omitempty"`
+	// Scope is one of ResourceScopeCluster or ResourceScopeNamespaced,
+	// an empty value is treated as ResourceScopeCluster.
+	Scope string `json:"scope,omitempty"`
 }
 
 // Validate ensures that the ManagedResource can be rendered to code
@@ -102,6 +146,11 @@ func (mr *ManagedResource) Validate() error {
 	if mr.PackagePath == "" {
 		fail.Append(InvalidMRPackagePathEmpty)
 	}
+	switch mr.Scope {
+	case "", ResourceScopeCluster, ResourceScopeNamespaced:
+	default:
+		fail.Append(InvalidMRScope)
+	}
 
 	if len(fail.Errors()) > 0 {
 		return fail
@@ -116,6 +165,15 @@ func (mr *ManagedResource) CategoryCSV() string {
 	return strings.Join(mr.CategoryTags, ",")
 }
 
+// ResourceScope returns the kubernetes scope of the resource,
+// defaulting to ResourceScopeCluster when Scope is not set.
+func (mr *ManagedResource) ResourceScope() string {
+	if mr.Scope == "" {
+		return ResourceScopeCluster
+	}
+	return mr.Scope
+}
+
 func (mr *ManagedResource) Namer() ResourceNamer {
 	return mr.namer
 }
@@ -175,3 +233,14 @@ func AttributeTypeDeclaration(f Field) string {
 	}
 	return "panic(\"unrecognized attribute type in pkg/generator/types.go:AttributeTypeDeclaration\")"
 }
+
+// AttributeTypeFromDeclaration is the inverse of AttributeTypeDeclaration,
+// mapping a go type name like "int64" to its AttributeType.
+func AttributeTypeFromDeclaration(decl string) (AttributeType, bool) {
+	for t := AttributeTypeUintptr; t <= AttributeTypeBool; t++ {
+		if AttributeTypeDeclaration(Field{AttributeField: AttributeField{Type: t}}) == decl {
+			return t, true
+		}
+	}
+	return AttributeTypeUnsupported, false
+}
@@ -118,6 +118,50 @@ func TestBaseWithValidation(t *testing.T) {
 	}
 }
 
+func TestResourceTypeCategoriesAndPrinterColumns(t *testing.T) {
+	mr := DefaultTestResource()
+	mr.CategoryTags = []string{"crossplane", "managed", "aws"}
+	mr.PrinterColumns = []PrinterColumn{
+		DefaultPrinterColumns()[2],
+		{Name: "STATE", Type: "string", JSONPath: ".status.atProvider.state", Priority: 1},
+	}
+	actual := ResourceTypeFragment(mr).Render()
+
+	expected := "// +kubebuilder:object:root=true\n" +
+		"\n" +
+		"// Test is a managed resource representing a resource mirrored in the cloud\n" +
+		"// +kubebuilder:printcolumn:name=\"EXTERNAL-NAME\",type=\"string\",JSONPath=\".metadata.annotations.crossplane\\\\.io/external-name\"\n" +
+		"// +kubebuilder:printcolumn:name=\"STATE\",type=\"string\",JSONPath=\".status.atProvider.state\",priority=1\n" +
+		"// +kubebuilder:subresource:status\n" +
+		"// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,aws}\n" +
+		"type Test struct {\n" +
+		"	metav1.TypeMeta   `json:\",inline\"`\n" +
+		"	metav1.ObjectMeta `json:\"metadata,omitempty\"`\n" +
+		"\n" +
+		"	Spec   TestSpec   `json:\"spec\"`\n" +
+		"	Status TestStatus `json:\"status,omitempty\"`\n" +
+		"}"
+
+	if actual != expected {
+		t.Errorf("Unexpected output from jen render.\nExpected:\n ---- \n%s\n ---- \nActual:\n%s", expected, actual)
+	}
+}
+
+func TestResourceAnnotationScope(t *testing.T) {
+	mr := DefaultTestResource()
+	if actual := RenderKubebuilderResourceAnnotation(mr); actual != "+kubebuilder:resource:scope=Cluster" {
+		t.Errorf("Expected resources to be cluster scoped by default, saw=%s", actual)
+	}
+	mr.Scope = ResourceScopeNamespaced
+	mr.CategoryTags = []string{"crossplane"}
+	expected := "+kubebuilder:resource:scope=Namespaced,categories={crossplane}"
+	if actual := RenderKubebuilderResourceAnnotation(mr); actual != expected {
+		t.Errorf("Unexpected resource annotation. expected=%s, actual=%s", expected, actual)
+	}
+	mr.Scope = "Global"
+	expectValidationError(InvalidMRScope, mr.Validate(), t)
+}
+
 func TestDefaultIsValid(t *testing.T) {
 	mr := DefaultTestResource()
 	err := mr.Validate()
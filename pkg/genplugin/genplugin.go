@@ -0,0 +1,169 @@
+// Package genplugin runs external generators, in the style of protoc-gen-*
+// plugins. A plugin is an executable which reads a JSON encoded Request from
+// its stdin and writes a JSON encoded Response to its stdout. It is run once
+// when the provider is bootstrapped, with a Request without a Resource, and
+// once for every generated resource, with the optimized ManagedResource that
+// the built-in templates render. The files of the Response are written by
+// terraform-provider-gen, so they are formatted, overlaid and tracked in the
+// manifest like any generated file.
+//
+// Anything written to stderr is passed through. A plugin reports a failure
+// by setting the Error of its Response, or by exiting with a non-zero status.
+// Plugins written in Go can use Main to handle the protocol.
+package genplugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	"github.com/hashicorp/terraform/providers"
+)
+
+// Plugin configures an external generator
+type Plugin struct {
+	// Name identifies the plugin in messages, the base name of Command when empty
+	Name string `json:"name,omitempty"`
+	// Command is the path of the executable, which is looked up in PATH when
+	// it has no directory, eg terraform-provider-gen-examples
+	Command string `json:"command"`
+	// Args are passed to Command
+	Args []string `json:"args,omitempty"`
+	// Parameter is passed to the plugin as the Parameter of every Request
+	Parameter string `json:"parameter,omitempty"`
+}
+
+// DisplayName returns the Name of the plugin, or the base name of its Command
+func (p Plugin) DisplayName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return filepath.Base(p.Command)
+}
+
+// Request is written to the stdin of a plugin
+type Request struct {
+	// Parameter is the Parameter configured for the plugin
+	Parameter string `json:"parameter,omitempty"`
+	// Provider describes the provider being generated
+	Provider Provider `json:"provider"`
+	// Resource is the resource to generate files for. It is nil when the
+	// provider is bootstrapped.
+	Resource *Resource `json:"resource,omitempty"`
+}
+
+// Provider describes the provider being generated
+type Provider struct {
+	Name                  string `json:"name"`
+	PackagePath           string `json:"packagePath"`
+	BaseCRDVersion        string `json:"baseCRDVersion"`
+	ProviderConfigVersion string `json:"providerConfigVersion"`
+	// Resources are the terraform names of every resource being generated
+	Resources []string `json:"resources"`
+}
+
+// Resource describes a resource along with the names it is generated with
+type Resource struct {
+	TerraformName string `json:"terraformName"`
+	PackageName   string `json:"packageName"`
+	APIVersion    string `json:"apiVersion"`
+	APIGroup      string `json:"apiGroup"`
+	Kind          string `json:"kind"`
+	ListKind      string `json:"listKind"`
+	// ManagedResource is the model rendered by the built-in templates
+	ManagedResource *generator.ManagedResource `json:"managedResource"`
+	// Schema is the terraform schema of the resource
+	Schema providers.Schema `json:"schema"`
+}
+
+// Response is read from the stdout of a plugin
+type Response struct {
+	// Files are written relative to the resource package, or to the provider
+	// base path when the provider is bootstrapped
+	Files []File `json:"files,omitempty"`
+	// Error is set when the plugin cannot generate files for the Request
+	Error string `json:"error,omitempty"`
+}
+
+// File is generated by a plugin
+type File struct {
+	// Name is a slash separated path, which must not leave the directory the
+	// file is written to
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// Run executes the plugin with req on its stdin, returning its Response. An
+// Error in the Response is returned as an error.
+func Run(p Plugin, req Request, stderr io.Writer) (*Response, error) {
+	req.Parameter = p.Parameter
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(p.Command, p.Args...)
+	cmd.Stdin = bytes.NewReader(in)
+	out := new(bytes.Buffer)
+	cmd.Stdout = out
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("plugin %s failed: %s", p.DisplayName(), err)
+	}
+	resp := &Response{}
+	if err := json.Unmarshal(out.Bytes(), resp); err != nil {
+		return nil, fmt.Errorf("plugin %s wrote an invalid response: %s", p.DisplayName(), err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s failed: %s", p.DisplayName(), resp.Error)
+	}
+	for _, f := range resp.Files {
+		if err := CheckPath(f.Name); err != nil {
+			return nil, fmt.Errorf("plugin %s returned file %q: %s", p.DisplayName(), f.Name, err)
+		}
+	}
+	return resp, nil
+}
+
+// CheckPath returns an error unless name is a relative path which does not
+// leave the directory it is relative to
+func CheckPath(name string) error {
+	if name == "" {
+		return fmt.Errorf("path is empty")
+	}
+	clean := filepath.ToSlash(filepath.Clean(name))
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("path must be relative and within its directory")
+	}
+	return nil
+}
+
+// Main implements the plugin protocol around generate, reading a Request
+// from stdin and writing the Response to stdout. An error from generate is
+// returned to terraform-provider-gen as the Error of the Response.
+func Main(generate func(req *Request) ([]File, error)) {
+	if err := serve(os.Stdin, os.Stdout, generate); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func serve(r io.Reader, w io.Writer, generate func(req *Request) ([]File, error)) error {
+	req := &Request{}
+	if err := json.NewDecoder(r).Decode(req); err != nil {
+		return fmt.Errorf("cannot decode request: %s", err)
+	}
+	resp := Response{}
+	files, err := generate(req)
+	if err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Files = files
+	}
+	return json.NewEncoder(w).Encode(resp)
+}
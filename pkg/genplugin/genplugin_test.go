@@ -0,0 +1,111 @@
+package genplugin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// pluginEnv makes the test binary act as a plugin, whose behaviour is chosen
+// by the Parameter of the request
+const pluginEnv = "GENPLUGIN_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(pluginEnv) != "" {
+		Main(testPlugin)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func testPlugin(req *Request) ([]File, error) {
+	switch req.Parameter {
+	case "error":
+		return nil, fmt.Errorf("cannot generate %s", req.Provider.Name)
+	case "escape":
+		return []File{{Name: "../escape.go", Content: "package escape\n"}}, nil
+	case "exit":
+		fmt.Fprintln(os.Stderr, "giving up")
+		os.Exit(3)
+	}
+	if req.Resource == nil {
+		return []File{{Name: "README.md", Content: "# " + req.Provider.Name + "\n"}}, nil
+	}
+	return []File{{Name: "examples/" + req.Resource.Kind + ".yaml", Content: "kind: " + req.Resource.Kind + "\n"}}, nil
+}
+
+func testPluginConfig(parameter string) Plugin {
+	return Plugin{Name: "test", Command: os.Args[0], Parameter: parameter}
+}
+
+func TestRun(t *testing.T) {
+	os.Setenv(pluginEnv, "1")
+	defer os.Unsetenv(pluginEnv)
+
+	req := Request{
+		Provider: Provider{Name: "example"},
+		Resource: &Resource{TerraformName: "example_thing", Kind: "Thing"},
+	}
+	resp, err := Run(testPluginConfig(""), req, os.Stderr)
+	if err != nil {
+		t.Fatalf("Unexpected error from Run: %s", err)
+	}
+	if len(resp.Files) != 1 || resp.Files[0].Name != "examples/Thing.yaml" || resp.Files[0].Content != "kind: Thing\n" {
+		t.Errorf("Unexpected files in response: %v", resp.Files)
+	}
+
+	resp, err = Run(testPluginConfig(""), Request{Provider: Provider{Name: "example"}}, os.Stderr)
+	if err != nil {
+		t.Fatalf("Unexpected error from Run: %s", err)
+	}
+	if len(resp.Files) != 1 || resp.Files[0].Name != "README.md" {
+		t.Errorf("Unexpected files in response to a provider request: %v", resp.Files)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	os.Setenv(pluginEnv, "1")
+	defer os.Unsetenv(pluginEnv)
+
+	cases := map[string]string{
+		"error":  "plugin test failed: cannot generate example",
+		"escape": `plugin test returned file "../escape.go": path must be relative and within its directory`,
+		"exit":   "plugin test failed: exit status 3",
+	}
+	for parameter, expected := range cases {
+		stderr := new(bytes.Buffer)
+		_, err := Run(testPluginConfig(parameter), Request{Provider: Provider{Name: "example"}}, stderr)
+		if err == nil || err.Error() != expected {
+			t.Errorf("%s: expected error %q, saw %v", parameter, expected, err)
+		}
+		if parameter == "exit" && !strings.Contains(stderr.String(), "giving up") {
+			t.Errorf("Expected the stderr of the plugin to be passed through, saw %q", stderr.String())
+		}
+	}
+}
+
+func TestRunMissingCommand(t *testing.T) {
+	_, err := Run(Plugin{Command: "/does/not/exist/protoc-gen-nothing"}, Request{}, os.Stderr)
+	if err == nil || !strings.HasPrefix(err.Error(), "plugin protoc-gen-nothing failed:") {
+		t.Errorf("Expected an error naming the plugin, saw %v", err)
+	}
+}
+
+func TestCheckPath(t *testing.T) {
+	for p, ok := range map[string]bool{
+		"a.go":          true,
+		"examples/a.go": true,
+		"a/../b.go":     true,
+		"":              false,
+		"/a.go":         false,
+		"..":            false,
+		"../a.go":       false,
+		"a/../../b.go":  false,
+	} {
+		if err := CheckPath(p); (err == nil) != ok {
+			t.Errorf("CheckPath(%q) = %v, expected ok=%v", p, err, ok)
+		}
+	}
+}
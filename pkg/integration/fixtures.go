@@ -3,8 +3,6 @@ package integration
 import (
 	"fmt"
 	"github.com/crossplane-contrib/terraform-provider-gen/pkg/optimize"
-	"io"
-	"os"
 	"path"
 
 	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
@@ -12,6 +10,7 @@ import (
 	"github.com/crossplane-contrib/terraform-provider-gen/pkg/translate"
 	"github.com/hashicorp/terraform/configs/configschema"
 	"github.com/hashicorp/terraform/providers"
+	"github.com/spf13/afero"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -358,16 +357,11 @@ func UpdateAllFixtures(itc *IntegrationTestConfig) error {
 			return err
 		}
 		p := path.Join(basePath, "pkg/integration", fxpath)
-		fp, err := os.OpenFile(p, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
-		if err != nil {
-			return err
-		}
-		_, err = io.WriteString(fp, contents)
-		fp.Close()
-		fmt.Printf("Wrote fixture %s", fxpath)
+		err = afero.WriteFile(itc.Fs(), p, []byte(contents), 0644)
 		if err != nil {
 			return err
 		}
+		fmt.Printf("Wrote fixture %s\n", fxpath)
 	}
 
 	return nil
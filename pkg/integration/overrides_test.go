@@ -0,0 +1,106 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/optimize"
+)
+
+func attributeFixture(name, jsonName string, t generator.AttributeType) generator.Field {
+	return generator.Field{
+		Name:           name,
+		Type:           generator.FieldTypeAttribute,
+		AttributeField: generator.AttributeField{Type: t},
+		Tag: &generator.StructTag{
+			Json: &generator.StructTagJson{
+				Name: jsonName,
+			},
+		},
+	}
+}
+
+func overrideTestResource() *generator.ManagedResource {
+	mr := DefaultTestResource()
+	nested := NestedFieldFixture("Outer", "Nested", "DeeplyNested").Fields[0]
+	nested.Fields = append(nested.Fields, attributeFixture("VolumeSize", "volume_size", generator.AttributeTypeInt64))
+	mr.Parameters.Fields = []generator.Field{
+		attributeFixture("Arn", "arn", generator.AttributeTypeString),
+		attributeFixture("Tags", "tags", generator.AttributeTypeString),
+		nested,
+	}
+	mr.Observation.Fields = []generator.Field{
+		attributeFixture("Status", "status", generator.AttributeTypeString),
+	}
+	return mr
+}
+
+func TestFieldOverrides(t *testing.T) {
+	mr := overrideTestResource()
+	overrider := optimize.NewFieldOverrider(map[string]optimize.FieldOverride{
+		"tags":                  {Ignore: true},
+		"arn":                   {Location: optimize.FieldLocationStatus},
+		"status":                {Immutable: true, Name: "state"},
+		"sub_field":             {Name: "block"},
+		"sub_field.volume_size": {Type: "float64", Immutable: true},
+	})
+	mr, err := overrider(mr)
+	if err != nil {
+		t.Fatalf("unexpected error applying overrides: %s", err)
+	}
+
+	if len(mr.Parameters.Fields) != 1 {
+		t.Fatalf("expected only the nested field to remain in Parameters, got %d fields", len(mr.Parameters.Fields))
+	}
+	block := mr.Parameters.Fields[0]
+	if block.Name != "Block" || block.Tag.Json.Name != "block" {
+		t.Errorf("expected sub_field to be renamed to Block/block, got %s/%s", block.Name, block.Tag.Json.Name)
+	}
+	var volumeSize *generator.Field
+	for i := range block.Fields {
+		if block.Fields[i].Name == "VolumeSize" {
+			volumeSize = &block.Fields[i]
+		}
+	}
+	if volumeSize == nil {
+		t.Fatalf("expected volume_size to still be present in the renamed block")
+	}
+	if volumeSize.AttributeField.Type != generator.AttributeTypeFloat64 {
+		t.Errorf("expected volume_size to be retyped to float64, got %s", generator.AttributeTypeDeclaration(*volumeSize))
+	}
+	if !volumeSize.Immutable {
+		t.Errorf("expected volume_size to be marked immutable")
+	}
+
+	if len(mr.Observation.Fields) != 2 {
+		t.Fatalf("expected arn to be moved into Observation, got %d fields", len(mr.Observation.Fields))
+	}
+	arn, state := mr.Observation.Fields[0], mr.Observation.Fields[1]
+	if arn.Name != "Arn" {
+		t.Errorf("expected Observation fields to be sorted with Arn first, got %s", arn.Name)
+	}
+	if state.Name != "State" || state.Tag.Json.Name != "state" || !state.Immutable {
+		t.Errorf("expected status to be renamed to an immutable State/state field, got %s/%s immutable=%t", state.Name, state.Tag.Json.Name, state.Immutable)
+	}
+}
+
+func TestFieldOverridesErrors(t *testing.T) {
+	mr := overrideTestResource()
+	overrider := optimize.NewFieldOverrider(map[string]optimize.FieldOverride{
+		"missing":               {Ignore: true},
+		"arn":                   {Type: "float64"},
+		"sub_field":             {Type: "int32"},
+		"sub_field.volume_size": {Location: optimize.FieldLocationStatus},
+	})
+	_, err := overrider(mr)
+	if err == nil {
+		t.Fatalf("expected an error for invalid overrides")
+	}
+	me, ok := err.(generator.MultiError)
+	if !ok {
+		t.Fatalf("expected a generator.MultiError, got %T", err)
+	}
+	if len(me.Errors()) != 4 {
+		t.Errorf("expected 4 errors, got %d: %s", len(me.Errors()), err)
+	}
+}
@@ -0,0 +1,464 @@
+package integration
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/pipeline"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/provider"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/template"
+	"github.com/spf13/afero"
+)
+
+// pipelineSetup generates the test schema with the test config into /provider
+// on an in-memory filesystem. Tests adjust cfg, tg and opts before calling
+// pipeline or all.
+type pipelineSetup struct {
+	t    *testing.T
+	cfg  provider.Config
+	fs   afero.Fs
+	tg   template.TemplateGetter
+	opts pipeline.Options
+	out  *bytes.Buffer
+}
+
+// newPipelineSetup writes files, keyed by path, to a new in-memory filesystem
+func newPipelineSetup(t *testing.T, files map[string]string) *pipelineSetup {
+	fs := afero.NewMemMapFs()
+	for p, content := range files {
+		if err := afero.WriteFile(fs, p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := testPipelineConfig()
+	cfg.BasePath = "/provider"
+	out := new(bytes.Buffer)
+	return &pipelineSetup{
+		t:    t,
+		cfg:  cfg,
+		fs:   fs,
+		tg:   template.NewCompiledTemplateGetter(),
+		opts: pipeline.Options{OutputDir: "/provider/generated/resources", Out: out},
+		out:  out,
+	}
+}
+
+// withUserTemplates renders the templates under /templates in place of the
+// compiled templates they override
+func (s *pipelineSetup) withUserTemplates() *pipelineSetup {
+	tg, err := template.NewUserTemplateGetter(s.fs, "/templates")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	s.tg = tg
+	return s
+}
+
+func (s *pipelineSetup) pipeline() *pipeline.Pipeline {
+	return pipeline.New(pipeline.StaticSchema(testPipelineSchema()), s.cfg, s.tg, s.fs, s.opts)
+}
+
+// all runs Pipeline.All, failing the test on an error
+func (s *pipelineSetup) all() {
+	if err := s.pipeline().All(); err != nil {
+		s.t.Fatalf("Unexpected error from Pipeline.All: %s", err)
+	}
+}
+
+// read returns the content of the file at p, failing the test when there is none
+func (s *pipelineSetup) read(p string) string {
+	b, err := afero.ReadFile(s.fs, p)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return string(b)
+}
+
+// expectFiles checks that each file, keyed by path, contains its value
+func (s *pipelineSetup) expectFiles(expected map[string]string) {
+	for p, content := range expected {
+		b, err := afero.ReadFile(s.fs, p)
+		if err != nil {
+			s.t.Errorf("Expected %s to be written: %s", p, err)
+			continue
+		}
+		if !strings.Contains(string(b), content) {
+			s.t.Errorf("Expected %s to contain %q, saw:\n%s", p, content, string(b))
+		}
+	}
+}
+
+func TestPipelineInMemory(t *testing.T) {
+	s := newPipelineSetup(t, nil)
+	s.opts.Jobs = 2
+	p := s.pipeline()
+
+	var mu sync.Mutex
+	optimized := make([]string, 0)
+	p.OnOptimize(func(name string, mr *generator.ManagedResource) error {
+		mu.Lock()
+		defer mu.Unlock()
+		optimized = append(optimized, name)
+		return nil
+	})
+	p.OnRender(func(path string, content []byte) ([]byte, error) {
+		if !strings.HasSuffix(path, ".go") {
+			return content, nil
+		}
+		return append([]byte("// Code generated by terraform-provider-gen. DO NOT EDIT.\n\n"), content...), nil
+	})
+	var changes []provider.FileChange
+	p.OnWrite(func(c []provider.FileChange) error {
+		changes = append(changes, c...)
+		return nil
+	})
+
+	if err := p.All(); err != nil {
+		t.Fatalf("Unexpected error from Pipeline.All: %s", err)
+	}
+	if len(optimized) != 3 {
+		t.Errorf("Expected the optimize hook to be called for each of 3 resources, saw %v", optimized)
+	}
+	if !strings.HasPrefix(s.read("/provider/generated/resources/flat_resource/v1alpha1/types.go"), "// Code generated by terraform-provider-gen. DO NOT EDIT.") {
+		t.Errorf("Expected the render hook to be applied to types.go")
+	}
+	if _, err := afero.ReadFile(s.fs, "/provider/cmd/provider/main.go"); err != nil {
+		t.Errorf("Expected the provider to be bootstrapped: %s", err)
+	}
+	if len(changes) == 0 {
+		t.Errorf("Expected the write hook to receive the changes to the output tree")
+	}
+	for _, c := range changes {
+		if c.Status != provider.FileCreated {
+			t.Errorf("Expected %s to be created, saw %s", c.Path, c.Status)
+		}
+	}
+}
+
+func TestPipelineAllFailureWritesNothing(t *testing.T) {
+	s := newPipelineSetup(t, nil)
+	p := s.pipeline()
+	p.OnRender(func(path string, content []byte) ([]byte, error) {
+		if strings.HasSuffix(path, "types.go") {
+			return nil, fmt.Errorf("cannot render %s", path)
+		}
+		return content, nil
+	})
+
+	if err := p.All(); err == nil {
+		t.Fatal("Expected an error from Pipeline.All")
+	}
+	if files := readFsTree(t, s.fs, "/provider"); len(files) != 0 {
+		t.Errorf("Expected a failed run to write no files, saw %v", files)
+	}
+	if _, err := s.fs.Stat("/provider"); !os.IsNotExist(err) {
+		t.Errorf("Expected a failed run to leave no directories behind")
+	}
+}
+
+func TestPipelineOverlays(t *testing.T) {
+	s := newPipelineSetup(t, map[string]string{
+		"/overlays/flat_resource/v1alpha1/types.go.txt":       "package v1alpha1 // types overlay\n",
+		"/overlays/flat_resource/v1alpha1/compare.go.txt":     "package v1alpha1 // compare overlay\n",
+		"/overlays/flat_resource/v1alpha1/comapre.go.txt":     "package v1alpha1 // typo\n",
+		"/provider-overlays/cmd/provider/main.go.txt":         "package main // main overlay\n",
+		"/provider-overlays/generated/index_resources.go.txt": "package generated // index overlay\n",
+	})
+	s.opts.OverlayDir = "/overlays"
+	s.opts.ProviderOverlayDir = "/provider-overlays"
+	p := s.pipeline()
+	if err := p.All(); err != nil {
+		t.Fatalf("Unexpected error from Pipeline.All: %s", err)
+	}
+
+	s.expectFiles(map[string]string{
+		"/provider/generated/resources/flat_resource/v1alpha1/types.go":   "package v1alpha1 // types overlay\n",
+		"/provider/generated/resources/flat_resource/v1alpha1/compare.go": "package v1alpha1 // compare overlay\n",
+		"/provider/cmd/provider/main.go":                                  "package main // main overlay\n",
+		"/provider/generated/index_resources.go":                          "package generated // index overlay\n",
+	})
+	if !strings.Contains(s.out.String(), "warning: overlay /overlays/flat_resource/v1alpha1/comapre.go.txt matched no generated file") {
+		t.Errorf("Expected the misspelt overlay to be reported, saw:\n%s", s.out.String())
+	}
+	if strings.Contains(s.out.String(), "warning: overlay /provider-overlays") {
+		t.Errorf("Expected every provider overlay to be applied, saw:\n%s", s.out.String())
+	}
+
+	// a partial run leaves the overlays of the files it does not generate
+	// unmatched, which is not worth a warning
+	for name, step := range map[string]func() error{"Bootstrap": p.Bootstrap, "Types": p.Types, "Runtime": p.Runtime} {
+		s.out.Reset()
+		if err := step(); err != nil {
+			t.Fatalf("Unexpected error from Pipeline.%s: %s", name, err)
+		}
+		if strings.Contains(s.out.String(), "warning: overlay") {
+			t.Errorf("Expected Pipeline.%s not to warn about overlays, saw:\n%s", name, s.out.String())
+		}
+	}
+}
+
+func TestPipelinePatchOverlays(t *testing.T) {
+	s := newPipelineSetup(t, map[string]string{
+		"/overlays/flat_resource/v1alpha1/compare.go.patch": `--- a/compare.go
++++ b/compare.go
+@@ -24,2 +24,2 @@
+-// mergeManagedResourceEntrypointTemplate
++// resourceMerger merges the observed state of a FlatResource into its spec
+ type resourceMerger struct{}
+`,
+		"/overlays/flat_resource/v1alpha1/compare.go.decls.txt": `package v1alpha1
+
+import "github.com/crossplane-contrib/terraform-runtime/pkg/plugin"
+
+// MergeFlatResource_DifferentResourceRefId never updates the reference, it is managed elsewhere
+func MergeFlatResource_DifferentResourceRefId(k *FlatResourceParameters, p *FlatResourceParameters, md *plugin.MergeDescription) bool {
+	return false
+}
+`,
+	})
+	s.opts.OverlayDir = "/overlays"
+	s.opts.Verify = true
+	s.all()
+
+	compare := s.read("/provider/generated/resources/flat_resource/v1alpha1/compare.go")
+	if !strings.Contains(compare, "// resourceMerger merges the observed state of a FlatResource into its spec\ntype resourceMerger struct{}") {
+		t.Errorf("Expected the patch to be applied to compare.go, saw:\n%s", compare)
+	}
+	if !strings.Contains(compare, "it is managed elsewhere\nfunc MergeFlatResource_DifferentResourceRefId(k *FlatResourceParameters, p *FlatResourceParameters, md *plugin.MergeDescription) bool {\n\treturn false\n}") {
+		t.Errorf("Expected MergeFlatResource_DifferentResourceRefId to be replaced, saw:\n%s", compare)
+	}
+	if strings.Count(compare, "func MergeFlatResource_DifferentResourceRefId") != 1 {
+		t.Errorf("Expected the replaced function to be declared once")
+	}
+	if !strings.Contains(compare, "func MergeFlatResource_Labels(") {
+		t.Errorf("Expected the rest of compare.go to still be generated")
+	}
+}
+
+func TestPipelineExtensionHooks(t *testing.T) {
+	hooks := `package v1alpha1
+
+import (
+	"github.com/crossplane-contrib/terraform-runtime/pkg/plugin"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func (e *ctyEncoder) PostEncode(r *FlatResource, v cty.Value) (cty.Value, error) {
+	vals := v.AsValueMap()
+	vals["required_name"] = cty.StringVal(r.Spec.ForProvider.RequiredName + "-suffix")
+	return cty.ObjectVal(vals), nil
+}
+
+func (e *ctyDecoder) PostDecode(r *FlatResource, v cty.Value) error {
+	r.Spec.ForProvider.RequiredName = v.AsValueMap()["required_name"].AsString()
+	return nil
+}
+
+func (r *resourceMerger) PostMerge(kube, prov *FlatResource, md *plugin.MergeDescription) {
+	if kube.Spec.ForProvider.RequiredName != prov.Spec.ForProvider.RequiredName {
+		md.NeedsProviderUpdate = true
+	}
+}
+`
+	hooksPath := "/provider/generated/resources/flat_resource/v1alpha1/hooks.go"
+	s := newPipelineSetup(t, map[string]string{hooksPath: hooks})
+	s.opts.Verify = true
+	if err := s.pipeline().All(); err != nil {
+		t.Fatalf("Expected hand-written hooks to type check against the generated package: %s", err)
+	}
+	if s.read(hooksPath) != hooks {
+		t.Errorf("Expected generation to leave hooks.go untouched")
+	}
+}
+
+func TestPipelineUserTemplates(t *testing.T) {
+	configure, err := ioutil.ReadFile("../../hack/template/pkg/generator/configure.go.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := "// Copyright Example Corp. All rights reserved.\n\n"
+	s := newPipelineSetup(t, map[string]string{
+		"/templates/pkg/generator/configure.go.tmpl": header + string(configure[strings.Index(string(configure), "package "):]),
+	}).withUserTemplates()
+	s.opts.Verify = true
+	s.all()
+	if configure := s.read("/provider/generated/resources/flat_resource/v1alpha1/configure.go"); !strings.HasPrefix(configure, header) {
+		t.Errorf("Expected configure.go to be rendered from the user template, saw:\n%s", configure)
+	}
+	if !strings.Contains(s.read("/provider/generated/resources/flat_resource/v1alpha1/doc.go"), "Copyright 2019 The Crossplane Authors.") {
+		t.Errorf("Expected doc.go to be rendered from the compiled template")
+	}
+
+	// dropping the user template regenerates the file from the compiled template
+	var changes []provider.FileChange
+	s.tg = template.NewCompiledTemplateGetter()
+	p := s.pipeline()
+	p.OnWrite(func(c []provider.FileChange) error {
+		changes = append(changes, c...)
+		return nil
+	})
+	if err := p.Runtime(); err != nil {
+		t.Fatalf("Unexpected error from Pipeline.Runtime: %s", err)
+	}
+	for _, c := range changes {
+		if c.Path == "resources/flat_resource/v1alpha1/configure.go" {
+			if c.Status != provider.FileUpdated {
+				t.Errorf("Expected configure.go to be updated when its template changed, saw %s", c.Status)
+			}
+			return
+		}
+	}
+	t.Errorf("Expected a change to configure.go, saw %v", changes)
+}
+
+func TestPipelineTemplateData(t *testing.T) {
+	s := newPipelineSetup(t, map[string]string{
+		"/templates/pkg/generator/doc.go.tmpl": `// Package {{ .PackageName }} holds {{ plural .ManagedResourceName }} of the {{ .Config.Name }} provider, schema version {{ .Schema.Version }}.
+//
+// Parameters:
+{{- range .Resource.Parameters.Fields }}
+//   {{ snake .Name }}
+{{- end }}
+package {{ .APIVersion }}
+`,
+		"/templates/provider/cmd/provider/main.go.tpl": `// Resources: {{ join ", " .Resources }}
+package main
+
+func main() {}
+`,
+	}).withUserTemplates()
+	s.all()
+
+	doc := s.read("/provider/generated/resources/flat_resource/v1alpha1/doc.go")
+	for _, expected := range []string{
+		"// Package flat_resource holds FlatResources of the " + s.cfg.Name + " provider, schema version 0.",
+		"//\trequired_name\n",
+		"//\tlabels\n",
+	} {
+		if !strings.Contains(doc, expected) {
+			t.Errorf("Expected doc.go to contain %q, saw:\n%s", expected, doc)
+		}
+	}
+	if main := s.read("/provider/cmd/provider/main.go"); !strings.HasPrefix(main, "// Resources: test_another_resource, test_flat_resource, test_other_resource\n") {
+		t.Errorf("Expected main.go to list the selected resources, saw:\n%s", main)
+	}
+}
+
+func TestPipelineConfigTemplates(t *testing.T) {
+	s := newPipelineSetup(t, map[string]string{
+		"/templates/extra/kind.go.tmpl": `package {{ .APIVersion }}
+
+// {{ .ManagedResourceName }}Kind is the kind of {{ .Resource.Name }}
+const {{ .ManagedResourceName }}Kind = "{{ .ManagedResourceName }}"
+`,
+		"/templates/extra/example.yaml.tmpl": `apiVersion: {{ .PackageName }}.{{ .Config.Name }}/{{ .APIVersion }}
+kind: {{ .ManagedResourceName }}
+`,
+		"/templates/extra/README.md.tmpl": `# {{ .Name }}
+
+{{ range .Resources }}- {{ . }}
+{{ end }}`,
+		"/overlays/other_resource/v1alpha1/examples/example.yaml.txt": "# hand written\n",
+	}).withUserTemplates()
+	s.cfg.ResourceTemplates = []provider.TemplateFile{
+		{Template: "extra/kind.go.tmpl", Output: "kind.go"},
+		{Template: "extra/example.yaml.tmpl", Output: "examples/example.yaml"},
+	}
+	s.cfg.ProviderTemplates = []provider.TemplateFile{
+		{Template: "extra/README.md.tmpl", Output: "README.md"},
+	}
+	s.opts.OverlayDir = "/overlays"
+	s.opts.Verify = true
+	s.all()
+
+	s.expectFiles(map[string]string{
+		"/provider/generated/resources/flat_resource/v1alpha1/kind.go":                "const FlatResourceKind = \"FlatResource\"\n",
+		"/provider/generated/resources/flat_resource/v1alpha1/examples/example.yaml":  "apiVersion: flat_resource." + s.cfg.Name + "/v1alpha1\nkind: FlatResource\n",
+		"/provider/generated/resources/other_resource/v1alpha1/examples/example.yaml": "# hand written\n",
+		"/provider/README.md": "- test_another_resource\n- test_flat_resource\n- test_other_resource\n",
+	})
+}
+
+func TestPipelineFromIR(t *testing.T) {
+	translated := newPipelineSetup(t, nil)
+	translated.opts.Verify = true
+	translated.all()
+
+	dumped, err := translated.pipeline().ManagedResourceIR("test_flat_resource", "yaml")
+	if err != nil {
+		t.Fatalf("Unexpected error from ManagedResourceIR: %s", err)
+	}
+	if _, err := translated.pipeline().ManagedResourceIR("test_missing_resource", "yaml"); err == nil {
+		t.Errorf("Expected an error dumping a resource missing from the schema")
+	}
+
+	// the IR as dumped renders exactly what translating the schema does
+	loaded := newPipelineSetup(t, map[string]string{"/ir/test_flat_resource.yaml": string(dumped)})
+	loaded.opts.IRDir = "/ir"
+	loaded.opts.Verify = true
+	loaded.all()
+	expected := readFsTree(t, translated.fs, "/provider/generated/resources")
+	for p, content := range readFsTree(t, loaded.fs, "/provider/generated/resources") {
+		if p == provider.ManifestFilename {
+			continue
+		}
+		if expected[p] != content {
+			t.Errorf("Expected %s generated from the dumped IR to match the translated schema, saw:\n%s", p, content)
+		}
+	}
+
+	// a hand edited IR is rendered as it is
+	edited := strings.Replace(string(dumped), "name: RequiredName", "name: Name", 1)
+	if edited == string(dumped) {
+		t.Fatalf("Expected the dumped IR to hold the RequiredName field:\n%s", string(dumped))
+	}
+	if err := afero.WriteFile(loaded.fs, "/ir/test_flat_resource.yaml", []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded.all()
+	typesPath := "/provider/generated/resources/flat_resource/v1alpha1/types.go"
+	if types := loaded.read(typesPath); !regexp.MustCompile("\tName +string +`json:\"required_name\"`").MatchString(types) {
+		t.Errorf("Expected the field renamed in the IR in types.go, saw:\n%s", types)
+	}
+
+	// the resource config is applied to a loaded IR, so config edits made
+	// after it was dumped are not lost
+	loaded.cfg.Resources = map[string]provider.ResourceConfig{
+		"test_flat_resource": {Scope: "Namespaced", Categories: []string{"edited"}},
+	}
+	loaded.all()
+	if types := loaded.read(typesPath); !strings.Contains(types, "+kubebuilder:resource:scope=Namespaced,categories={edited}") {
+		t.Errorf("Expected the scope and categories of the resource config in types.go, saw:\n%s", types)
+	}
+}
+
+func TestPipelineResourceScope(t *testing.T) {
+	s := newPipelineSetup(t, nil)
+	s.cfg.Resources = map[string]provider.ResourceConfig{
+		"test_other_resource": {Scope: generator.ResourceScopeNamespaced},
+	}
+	s.opts.Verify = true
+	s.all()
+
+	// the scope only changes the CRD, the runtime registers and reconciles
+	// cluster and namespaced resources alike
+	tree := readFsTree(t, s.fs, "/provider/generated/resources")
+	for pkg, scope := range map[string]string{"flat_resource": "Cluster", "other_resource": "Namespaced"} {
+		types := tree[pkg+"/v1alpha1/types.go"]
+		if !strings.Contains(types, "+kubebuilder:resource:scope="+scope+"\n") {
+			t.Errorf("Expected %s to be %s scoped, saw:\n%s", pkg, scope, types)
+		}
+	}
+	flat := strings.NewReplacer("FlatResource", "Kind", "flat-resource", "group", "test_flat_resource", "name").Replace(tree["flat_resource/v1alpha1/index.go"])
+	other := strings.NewReplacer("OtherResource", "Kind", "other-resource", "group", "test_other_resource", "name").Replace(tree["other_resource/v1alpha1/index.go"])
+	if flat != other {
+		t.Errorf("Expected the same registration for cluster and namespaced resources, saw:\n%s\n\n%s", flat, other)
+	}
+}
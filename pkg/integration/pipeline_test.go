@@ -0,0 +1,380 @@
+package integration
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/optimize"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/provider"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/template"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/spf13/afero"
+)
+
+func testPipelineConfig() provider.Config {
+	return provider.Config{
+		Name:                  "test",
+		PackagePath:           FakePackagePath,
+		BaseCRDVersion:        DefaultAPIVersion,
+		ProviderConfigVersion: DefaultAPIVersion,
+	}
+}
+
+func testPipelineSchema() providers.GetSchemaResponse {
+	return providers.GetSchemaResponse{
+		ResourceTypes: map[string]providers.Schema{
+			"test_flat_resource":    testFixtureFlatBlock(),
+			"test_other_resource":   testFixtureFlatBlock(),
+			"test_another_resource": testFixtureFlatBlock(),
+		},
+	}
+}
+
+// schemaSetup generates the test schema with a SchemaTranslator into
+// generated/resources under dir on fs. Tests set overlayPath and adjust the
+// config passed to translator before generating.
+type schemaSetup struct {
+	t           *testing.T
+	fs          afero.Fs
+	dir         string
+	basePath    string
+	overlayPath string
+	out         *bytes.Buffer
+}
+
+// newSchemaSetup generates into /provider on an in-memory filesystem
+func newSchemaSetup(t *testing.T) *schemaSetup {
+	return newSchemaSetupIn(t, afero.NewMemMapFs(), "/provider")
+}
+
+// newOsSchemaSetup generates into a temporary directory on the OS filesystem,
+// for tests which depend on its file modes. The returned function removes the
+// directory.
+func newOsSchemaSetup(t *testing.T) (*schemaSetup, func()) {
+	dir, err := ioutil.TempDir("", "generate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newSchemaSetupIn(t, afero.NewOsFs(), dir), func() { os.RemoveAll(dir) }
+}
+
+func newSchemaSetupIn(t *testing.T, fs afero.Fs, dir string) *schemaSetup {
+	return &schemaSetup{
+		t:        t,
+		fs:       fs,
+		dir:      dir,
+		basePath: path.Join(dir, "generated", "resources"),
+		out:      new(bytes.Buffer),
+	}
+}
+
+// translator returns a SchemaTranslator generating the test schema with cfg
+func (s *schemaSetup) translator(cfg provider.Config) *provider.SchemaTranslator {
+	return provider.NewSchemaTranslator(cfg, s.basePath, s.overlayPath, testPipelineSchema(), template.NewCompiledTemplateGetter()).
+		WithFs(s.fs).
+		WithOutput(s.out)
+}
+
+// generateAll runs WriteGeneratedAll with st, failing the test on an error
+func (s *schemaSetup) generateAll(st *provider.SchemaTranslator) {
+	if err := st.WriteGeneratedAll(); err != nil {
+		s.t.Fatalf("Unexpected error from WriteGeneratedAll: %s", err)
+	}
+}
+
+// tree maps every file in the output root, relative to the root, to its contents
+func (s *schemaSetup) tree() map[string]string {
+	return readFsTree(s.t, s.fs, path.Dir(s.basePath))
+}
+
+// resourcePath joins elem to the output directory of the named resource package
+func (s *schemaSetup) resourcePath(pkg string, elem ...string) string {
+	return path.Join(append([]string{s.basePath, pkg, DefaultAPIVersion}, elem...)...)
+}
+
+func TestWriteGeneratedAll(t *testing.T) {
+	s := newSchemaSetup(t)
+	s.generateAll(s.translator(testPipelineConfig()))
+	for _, f := range []string{"types.go", "doc.go", "encode.go", "decode.go", "compare.go", "configure.go", "index.go"} {
+		if _, err := s.fs.Stat(s.resourcePath("flat_resource", f)); err != nil {
+			t.Errorf("Expected WriteGeneratedAll to write %s: %s", f, err)
+		}
+	}
+	if _, err := s.fs.Stat(path.Join(s.dir, "generated", "index_resources.go")); err != nil {
+		t.Errorf("Expected WriteGeneratedAll to write index_resources.go: %s", err)
+	}
+}
+
+// readFsTree maps the path of every file under dir on fs, relative to dir, to its contents
+func readFsTree(t *testing.T, fs afero.Fs, dir string) map[string]string {
+	tree := make(map[string]string)
+	err := afero.Walk(fs, dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		b, err := afero.ReadFile(fs, p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		tree[rel] = string(b)
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestWriteGeneratedAllMemMapFs(t *testing.T) {
+	onDisk, cleanup := newOsSchemaSetup(t)
+	defer cleanup()
+	onDisk.generateAll(onDisk.translator(testPipelineConfig()))
+	expected := onDisk.tree()
+
+	s := newSchemaSetup(t)
+	s.generateAll(s.translator(testPipelineConfig()))
+	tree := s.tree()
+	if !reflect.DeepEqual(tree, expected) {
+		for p := range expected {
+			if _, ok := tree[p]; !ok {
+				t.Errorf("Expected %s to be written to the MemMapFs", p)
+			} else if tree[p] != expected[p] {
+				t.Errorf("Expected %s in the MemMapFs to match the OS filesystem", p)
+			}
+		}
+		for p := range tree {
+			if _, ok := expected[p]; !ok {
+				t.Errorf("Unexpected file %s in the MemMapFs", p)
+			}
+		}
+	}
+}
+
+func TestWriteGeneratedAllJobsDeterministic(t *testing.T) {
+	trees := make([]map[string]string, 0)
+	for _, jobs := range []int{1, 8} {
+		s := newSchemaSetup(t)
+		if err := s.translator(testPipelineConfig()).WithJobs(jobs).WriteGeneratedAll(); err != nil {
+			t.Fatalf("Unexpected error from WriteGeneratedAll with jobs=%d: %s", jobs, err)
+		}
+		trees = append(trees, s.tree())
+	}
+	if len(trees[0]) != len(trees[1]) {
+		t.Fatalf("Expected the same number of files regardless of jobs, saw %d and %d", len(trees[0]), len(trees[1]))
+	}
+	for p, contents := range trees[0] {
+		if trees[1][p] != contents {
+			t.Errorf("Expected %s to be identical regardless of jobs", p)
+		}
+	}
+}
+
+func TestWriteGeneratedAllAggregatesErrors(t *testing.T) {
+	s := newSchemaSetup(t)
+	cfg := testPipelineConfig()
+	s.generateAll(s.translator(cfg))
+	before := s.tree()
+
+	cfg.FieldOverrides = map[string]optimize.FieldOverride{
+		"test_other_resource.missing":   {Ignore: true},
+		"test_another_resource.missing": {Ignore: true},
+	}
+	cfg.Resources = map[string]provider.ResourceConfig{
+		"test_flat_resource": {Categories: []string{"changed"}},
+	}
+	err := s.translator(cfg).WithJobs(2).WriteGeneratedAll()
+	me, ok := err.(generator.MultiError)
+	if !ok {
+		t.Fatalf("Expected a generator.MultiError from WriteGeneratedAll, got %v", err)
+	}
+	if len(me.Errors()) != 2 {
+		t.Errorf("Expected an error for each failing resource, saw %d:\n%s", len(me.Errors()), err)
+	}
+	if !reflect.DeepEqual(before, s.tree()) {
+		t.Errorf("Expected a failed run to leave the output tree untouched")
+	}
+}
+
+func TestWriteGeneratedAllFileModes(t *testing.T) {
+	s, cleanup := newOsSchemaSetup(t)
+	defer cleanup()
+	s.generateAll(s.translator(testPipelineConfig()))
+	err := filepath.Walk(path.Join(s.dir, "generated"), func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && info.Mode().Perm() != 0644 {
+			t.Errorf("Expected %s to have mode 0644, saw %s", p, info.Mode().Perm())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ioutil.ReadDir(path.Join(s.dir, "generated"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), provider.StagingDirPrefix) {
+			t.Errorf("Expected the staging directory %s to be removed", e.Name())
+		}
+	}
+}
+
+func TestWriteGeneratedAllIncremental(t *testing.T) {
+	s := newSchemaSetup(t)
+	typesPath := s.resourcePath("flat_resource", "types.go")
+	cfg := testPipelineConfig()
+	st := s.translator(cfg)
+	s.generateAll(st)
+	before, err := s.fs.Stat(typesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.fs.Stat(path.Join(s.dir, "generated", provider.ManifestFilename)); err != nil {
+		t.Errorf("Expected a manifest to be written: %s", err)
+	}
+
+	// backdate the file so a rewrite would be visible in the mtime
+	past := before.ModTime().Add(-time.Hour)
+	if err := s.fs.Chtimes(typesPath, past, past); err != nil {
+		t.Fatal(err)
+	}
+	s.generateAll(st)
+	after, err := s.fs.Stat(typesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.ModTime().Equal(past) {
+		t.Errorf("Expected unchanged types.go not to be rewritten")
+	}
+
+	cfg.Categories = []string{"crossplane", "test"}
+	s.generateAll(s.translator(cfg))
+	after, err = s.fs.Stat(typesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.ModTime().Equal(past) {
+		t.Errorf("Expected types.go to be rewritten when the categories change")
+	}
+}
+
+func TestWriteGeneratedAllDryRun(t *testing.T) {
+	s := newSchemaSetup(t)
+	s.generateAll(s.translator(testPipelineConfig()).WithWriterOptions(provider.WriterOptions{DryRun: true}))
+	if tree := readFsTree(t, s.fs, "/"); len(tree) != 0 {
+		t.Errorf("Expected a dry run not to write any files, saw %d", len(tree))
+	}
+}
+
+func TestWriteGeneratedAllPrune(t *testing.T) {
+	s := newSchemaSetup(t)
+	otherDir := path.Join(s.basePath, "other_resource")
+	anotherDir := path.Join(s.basePath, "another_resource")
+
+	cfg := testPipelineConfig()
+	s.generateAll(s.translator(cfg))
+	if _, err := s.fs.Stat(s.resourcePath("other_resource", provider.OwnerMarkerFilename)); err != nil {
+		t.Fatalf("Expected an owner marker in each resource package: %s", err)
+	}
+
+	cfg.ExcludeResources = []provider.ResourceRule{{Pattern: "test_other_resource"}, {Pattern: "test_another_resource"}}
+	s.generateAll(s.translator(cfg))
+	if _, err := s.fs.Stat(otherDir); err != nil {
+		t.Errorf("Expected the stale package to be kept without pruning: %s", err)
+	}
+
+	// a hand-written file is not removed along with the generated files
+	hooks := s.resourcePath("other_resource", "hooks.go")
+	if err := afero.WriteFile(s.fs, hooks, []byte("package v1alpha1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s.out.Reset()
+	s.generateAll(s.translator(cfg).WithPrune(true))
+	if _, err := s.fs.Stat(anotherDir); !os.IsNotExist(err) {
+		t.Errorf("Expected the stale package to be pruned, stat returned %v", err)
+	}
+	for _, name := range []string{"types.go", "index.go", provider.OwnerMarkerFilename} {
+		if _, err := s.fs.Stat(s.resourcePath("other_resource", name)); !os.IsNotExist(err) {
+			t.Errorf("Expected the generated %s to be pruned, stat returned %v", name, err)
+		}
+	}
+	if _, err := s.fs.Stat(hooks); err != nil {
+		t.Errorf("Expected the hand-written hooks.go to be kept: %s", err)
+	}
+	if !strings.Contains(s.out.String(), "holds files which were not generated:\n  "+hooks+"\n") {
+		t.Errorf("Expected the kept hooks.go to be reported, saw:\n%s", s.out.String())
+	}
+	if _, err := s.fs.Stat(s.resourcePath("flat_resource", "types.go")); err != nil {
+		t.Errorf("Expected packages for generated resources to be kept: %s", err)
+	}
+	index, err := afero.ReadFile(s.fs, path.Join(s.dir, "generated", "index_resources.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(index), "/other_resource/") {
+		t.Errorf("Expected the pruned package to be dropped from index_resources.go")
+	}
+}
+
+func TestWriteGeneratedAllVerify(t *testing.T) {
+	s := newSchemaSetup(t)
+	s.overlayPath = path.Join(s.dir, "overlays")
+	st := s.translator(testPipelineConfig()).WithVerify(true)
+	if err := st.WriteGeneratedAll(); err != nil {
+		t.Fatalf("Expected generated packages to type check: %s", err)
+	}
+	before := s.tree()
+
+	// an overlay is written as it is, so an unused import makes the package fail to compile
+	overlay := "package v1alpha1\n\nimport \"fmt\"\n\ntype reconcilerConfigurer struct{}\n"
+	if err := afero.WriteFile(s.fs, path.Join(s.overlayPath, "other_resource", DefaultAPIVersion, "configure.go.txt"), []byte(overlay), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := st.WriteGeneratedAll()
+	if err == nil {
+		t.Fatalf("Expected verification to fail for an overlay which does not compile")
+	}
+	if !strings.Contains(err.Error(), "configure.go:3:8: \"fmt\" imported and not used") {
+		t.Errorf("Expected the type error to name the file and position, saw:\n%s", err)
+	}
+	if !reflect.DeepEqual(before, s.tree()) {
+		t.Errorf("Expected nothing to be written when verification fails")
+	}
+}
+
+func TestWriteGeneratedAllVerifyRenderedHook(t *testing.T) {
+	s := newSchemaSetup(t)
+	hooks := provider.Hooks{
+		Rendered: func(p string, content []byte) ([]byte, error) {
+			if !strings.HasSuffix(p, "flat_resource/v1alpha1/configure.go") {
+				return content, nil
+			}
+			return append(content, []byte("\nfunc broken() { undefinedByHook() }\n")...), nil
+		},
+	}
+	err := s.translator(testPipelineConfig()).WithHooks(hooks).WithVerify(true).WriteGeneratedAll()
+	if err == nil {
+		t.Fatalf("Expected verification to fail when a Rendered hook writes invalid Go")
+	}
+	if !strings.Contains(err.Error(), "undefined: undefinedByHook") {
+		t.Errorf("Expected the type error introduced by the hook, saw:\n%s", err)
+	}
+	if len(readFsTree(t, s.fs, s.dir)) != 0 {
+		t.Errorf("Expected nothing to be written when verification fails")
+	}
+}
@@ -0,0 +1,63 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/genplugin"
+)
+
+// testPluginEnv makes the test binary act as the plugin of TestPipelinePlugins
+const testPluginEnv = "TERRAFORM_PROVIDER_GEN_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testPluginEnv) != "" {
+		genplugin.Main(testPlugin)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// testPlugin lists the parameters of each resource in a Go file, and the
+// resources of the provider in a README
+func testPlugin(req *genplugin.Request) ([]genplugin.File, error) {
+	if req.Resource == nil {
+		return []genplugin.File{{
+			Name:    "README.md",
+			Content: fmt.Sprintf("# %s\n\n%s\n", req.Provider.Name, strings.Join(req.Provider.Resources, "\n")),
+		}}, nil
+	}
+	mr := req.Resource.ManagedResource
+	names := make([]string, 0)
+	for _, f := range mr.Parameters.Fields {
+		names = append(names, fmt.Sprintf("%q", f.Tag.Json.Name))
+	}
+	src := fmt.Sprintf("package %s\n\n// %sParameterNames are the %s parameters\nvar %sParameterNames = []string{%s}\n",
+		req.Resource.APIVersion, mr.Name, req.Parameter, mr.Name, strings.Join(names, ", "))
+	return []genplugin.File{
+		{Name: "parameters.go", Content: src},
+		{Name: "examples/" + req.Resource.PackageName + ".yaml", Content: "kind: " + req.Resource.Kind + "\n"},
+	}, nil
+}
+
+func TestPipelinePlugins(t *testing.T) {
+	os.Setenv(testPluginEnv, "1")
+	defer os.Unsetenv(testPluginEnv)
+
+	s := newPipelineSetup(t, map[string]string{
+		"/overlays/other_resource/v1alpha1/examples/other_resource.yaml.txt": "# hand written\n",
+	})
+	s.cfg.Plugins = []genplugin.Plugin{{Name: "test", Command: os.Args[0], Parameter: "terraform"}}
+	s.opts.OverlayDir = "/overlays"
+	s.opts.Verify = true
+	s.all()
+
+	s.expectFiles(map[string]string{
+		"/provider/generated/resources/flat_resource/v1alpha1/parameters.go":                 "// FlatResourceParameterNames are the terraform parameters\nvar FlatResourceParameterNames = []string{",
+		"/provider/generated/resources/flat_resource/v1alpha1/examples/flat_resource.yaml":   "kind: FlatResource\n",
+		"/provider/generated/resources/other_resource/v1alpha1/examples/other_resource.yaml": "# hand written\n",
+		"/provider/README.md": "# test\n\ntest_another_resource\ntest_flat_resource\ntest_other_resource\n",
+	})
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/crossplane-contrib/terraform-provider-gen/pkg/template"
 	"github.com/crossplane-contrib/terraform-runtime/pkg/client"
+	"github.com/spf13/afero"
 )
 
 var (
@@ -39,6 +40,7 @@ type IntegrationTestConfig struct {
 	providerName string
 	pluginPath   string
 	repoRoot     string
+	fs           afero.Fs
 }
 
 func (itc *IntegrationTestConfig) ProviderName() (string, error) {
@@ -62,6 +64,14 @@ func (itc *IntegrationTestConfig) RepoRoot() (string, error) {
 	return os.Getwd()
 }
 
+// Fs is the filesystem fixtures are written to, the OS filesystem unless set with WithFs
+func (itc *IntegrationTestConfig) Fs() afero.Fs {
+	if itc.fs != nil {
+		return itc.fs
+	}
+	return afero.NewOsFs()
+}
+
 func (itc *IntegrationTestConfig) TemplateGetter() (template.TemplateGetter, error) {
 	p, err := itc.RepoRoot()
 	if err != nil {
@@ -90,6 +100,12 @@ func WithProvidername(providerName string) TestConfigOption {
 	}
 }
 
+func WithFs(fs afero.Fs) TestConfigOption {
+	return func(itc *IntegrationTestConfig) {
+		itc.fs = fs
+	}
+}
+
 func NewIntegrationTestConfig(opts ...TestConfigOption) *IntegrationTestConfig {
 	itc := &IntegrationTestConfig{}
 	for _, opt := range opts {
@@ -0,0 +1,182 @@
+package optimize
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	"github.com/iancoleman/strcase"
+)
+
+const (
+	FieldLocationSpec   = "spec"
+	FieldLocationStatus = "status"
+)
+
+// FieldOverride adjusts a single field of a ManagedResource after translation.
+// Fields are addressed by their path of terraform attribute names, eg
+// root_block_device.volume_size.
+type FieldOverride struct {
+	// Ignore drops the field from the generated types and runtime methods
+	Ignore bool `json:"ignore,omitempty"`
+	// Location forces a top-level field into spec (forProvider) or status (atProvider),
+	// overriding the classification made by translate.SpecOrStatus
+	Location string `json:"location,omitempty"`
+	// Name renames the field. The value is a terraform style snake_case name
+	// which is used for the json tag and camel cased for the go field name.
+	// The terraform attribute name used to encode and decode the field is unchanged.
+	Name string `json:"name,omitempty"`
+	// Type changes the go type of a scalar numeric field, eg int32 or float64
+	Type string `json:"type,omitempty"`
+	// Immutable marks the field with a +immutable comment
+	Immutable bool `json:"immutable,omitempty"`
+}
+
+// Validate checks the override values without reference to a resource
+func (fo FieldOverride) Validate() error {
+	switch fo.Location {
+	case "", FieldLocationSpec, FieldLocationStatus:
+	default:
+		return fmt.Errorf("location %q is not one of %s or %s", fo.Location, FieldLocationSpec, FieldLocationStatus)
+	}
+	if fo.Type != "" {
+		if _, ok := generator.AttributeTypeFromDeclaration(fo.Type); !ok {
+			return fmt.Errorf("type %q is not a supported go type", fo.Type)
+		}
+	}
+	if fo.Ignore && (fo.Location != "" || fo.Name != "" || fo.Type != "" || fo.Immutable) {
+		return fmt.Errorf("ignore can not be combined with other overrides")
+	}
+	return nil
+}
+
+// numericAttributeTypes are the types a number can be retyped to, encoders
+// and decoders know how to convert each of these to and from a cty.Number.
+var numericAttributeTypes = map[generator.AttributeType]bool{
+	generator.AttributeTypeInt:     true,
+	generator.AttributeTypeInt8:    true,
+	generator.AttributeTypeInt16:   true,
+	generator.AttributeTypeInt32:   true,
+	generator.AttributeTypeInt64:   true,
+	generator.AttributeTypeUint:    true,
+	generator.AttributeTypeUint8:   true,
+	generator.AttributeTypeUint16:  true,
+	generator.AttributeTypeUint32:  true,
+	generator.AttributeTypeUint64:  true,
+	generator.AttributeTypeFloat32: true,
+	generator.AttributeTypeFloat64: true,
+}
+
+// NewFieldOverrider returns an Optimizer applying the overrides, keyed by
+// field path, to a ManagedResource. Overrides are applied deepest path first
+// so that renaming a parent does not prevent its children from being found.
+func NewFieldOverrider(overrides map[string]FieldOverride) Optimizer {
+	return func(mr *generator.ManagedResource) (*generator.ManagedResource, error) {
+		paths := make([]string, 0, len(overrides))
+		for p := range overrides {
+			paths = append(paths, p)
+		}
+		sort.Slice(paths, func(i, j int) bool {
+			di, dj := strings.Count(paths[i], "."), strings.Count(paths[j], ".")
+			if di != dj {
+				return di > dj
+			}
+			return paths[i] < paths[j]
+		})
+		fail := generator.NewMultiError(fmt.Sprintf("failed to apply field overrides to %s:", mr.Name))
+		for _, p := range paths {
+			if err := applyFieldOverride(mr, p, overrides[p]); err != nil {
+				fail.Append(fmt.Errorf("%s: %s", p, err))
+			}
+		}
+		if len(fail.Errors()) > 0 {
+			return nil, fail
+		}
+		return mr, nil
+	}
+}
+
+func applyFieldOverride(mr *generator.ManagedResource, fieldPath string, fo FieldOverride) error {
+	if err := fo.Validate(); err != nil {
+		return err
+	}
+	names := strings.Split(fieldPath, ".")
+	parent, idx, isSpec := findField(mr, names)
+	if parent == nil {
+		return fmt.Errorf("field not found")
+	}
+	if fo.Ignore {
+		parent.Fields = append(parent.Fields[:idx], parent.Fields[idx+1:]...)
+		return nil
+	}
+
+	f := parent.Fields[idx]
+	if fo.Type != "" {
+		t, _ := generator.AttributeTypeFromDeclaration(fo.Type)
+		if f.Type != generator.FieldTypeAttribute || f.IsSlice || f.AttributeField.Type == generator.AttributeTypeMapStringKey {
+			return fmt.Errorf("only scalar attributes can be retyped")
+		}
+		if !numericAttributeTypes[f.AttributeField.Type] || !numericAttributeTypes[t] {
+			return fmt.Errorf("can not retype %s to %s, only numeric types can be changed", generator.AttributeTypeDeclaration(f), fo.Type)
+		}
+		f.AttributeField.Type = t
+	}
+	if fo.Immutable {
+		f.Immutable = true
+	}
+	if fo.Name != "" {
+		f.Name = strcase.ToCamel(fo.Name)
+		if f.Tag != nil && f.Tag.Json != nil {
+			// copy the tag, Fields are copied by value but share tag pointers
+			tag := *f.Tag.Json
+			tag.Name = fo.Name
+			f.Tag = &generator.StructTag{Json: &tag}
+		}
+	}
+
+	dest := parent
+	if fo.Location != "" && (fo.Location == FieldLocationSpec) != isSpec {
+		if len(names) > 1 {
+			return fmt.Errorf("only top-level fields can be moved between spec and status")
+		}
+		dest = &mr.Observation
+		if fo.Location == FieldLocationSpec {
+			dest = &mr.Parameters
+		}
+	}
+	if dest == parent {
+		parent.Fields[idx] = f
+	} else {
+		parent.Fields = append(parent.Fields[:idx], parent.Fields[idx+1:]...)
+		dest.Fields = append(dest.Fields, f)
+	}
+	sort.Stable(generator.NamedFields(dest.Fields))
+	return nil
+}
+
+// findField walks the path of json tag names from the top of the spec or status
+// field tree, returning the parent of the matching field, the index of the field
+// within the parent, and whether the field was found in the spec.
+func findField(mr *generator.ManagedResource, names []string) (*generator.Field, int, bool) {
+	for _, root := range []*generator.Field{&mr.Parameters, &mr.Observation} {
+		current := root
+		for depth, name := range names {
+			idx := -1
+			for i, f := range current.Fields {
+				if f.Tag != nil && f.Tag.Json != nil && f.Tag.Json.Name == name {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				break
+			}
+			if depth == len(names)-1 {
+				return current, idx, root == &mr.Parameters
+			}
+			current = &current.Fields[idx]
+		}
+	}
+	return nil, -1, false
+}
@@ -0,0 +1,316 @@
+// Package pipeline is the programmatic interface to terraform-provider-gen.
+// A Pipeline loads a provider schema from a SchemaSource and generates the
+// provider described by a Config into a filesystem, through four stages:
+//
+//	translate: each resource schema becomes a generator.ManagedResource
+//	optimize:  field overrides and the optimizers are applied to it
+//	render:    the templates are executed to produce each file
+//	write:     files are staged, compared to the manifest and moved into place
+//
+// Hooks can be registered to inspect or change the output of each stage.
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/provider"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/template"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/translate"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/spf13/afero"
+)
+
+// Options control where and how a Pipeline writes its output
+type Options struct {
+	// OutputDir is the directory the resource packages are generated into
+	OutputDir string
+	// OverlayDir holds files used in place of generated files, mirroring OutputDir
+	OverlayDir string
+	// ProviderOverlayDir holds files used in place of the generated provider
+	// files, such as cmd/provider/main.go, mirroring the BasePath of the Config
+	ProviderOverlayDir string
+	// Jobs is the number of resources generated concurrently, 0 uses one per CPU
+	Jobs int
+	// Writer controls whether files are written, or only reported as a dry run
+	Writer provider.WriterOptions
+	// Prune removes generated packages of resources which are no longer generated
+	Prune bool
+	// Verify type checks each resource package before anything is written
+	Verify bool
+	// IRDir holds ManagedResources, as written by ManagedResourceIR, used in
+	// place of translating the schema of the resources they describe
+	IRDir string
+	// Out receives progress messages and reports, os.Stdout when nil
+	Out io.Writer
+}
+
+// ResourceHook is called with a resource at the end of the translate and optimize stages
+type ResourceHook func(name string, mr *generator.ManagedResource) error
+
+// RenderHook is called with each rendered file, and returns the content to write
+type RenderHook func(path string, content []byte) ([]byte, error)
+
+// WriteHook is called with the changes made to the output tree
+type WriteHook func(changes []provider.FileChange) error
+
+// Pipeline holds the SchemaSource, Config, templates, filesystem and Options
+// of a provider, along with the hooks registered for each stage. Bootstrap,
+// Types, Runtime and All each load the schema and run the stages for their
+// part of the provider, calling the hooks of a stage in the order they were
+// registered. A Pipeline can be run any number of times.
+type Pipeline struct {
+	source SchemaSource
+	cfg    provider.Config
+	tg     template.TemplateGetter
+	fs     afero.Fs
+	opts   Options
+	// overlays are shared by the Bootstrapper and the SchemaTranslator, since
+	// both write provider files
+	overlays *provider.Overlays
+
+	translated []ResourceHook
+	optimized  []ResourceHook
+	rendered   []RenderHook
+	written    []WriteHook
+}
+
+// New returns a Pipeline generating the provider described by cfg, from the
+// schema given by source, into fs.
+func New(source SchemaSource, cfg provider.Config, tg template.TemplateGetter, fs afero.Fs, opts Options) *Pipeline {
+	if opts.Out == nil {
+		opts.Out = os.Stdout
+	}
+	return &Pipeline{
+		source:   source,
+		cfg:      cfg,
+		tg:       tg,
+		fs:       fs,
+		opts:     opts,
+		overlays: provider.NewOverlays(fs, opts.ProviderOverlayDir, cfg.BasePath),
+	}
+}
+
+// OnTranslate registers a hook called with each resource as translated from its schema
+func (p *Pipeline) OnTranslate(hook ResourceHook) *Pipeline {
+	p.translated = append(p.translated, hook)
+	return p
+}
+
+// OnOptimize registers a hook called with each resource after it is optimized, before it is rendered
+func (p *Pipeline) OnOptimize(hook ResourceHook) *Pipeline {
+	p.optimized = append(p.optimized, hook)
+	return p
+}
+
+// OnRender registers a hook which may replace the content of each file before it is written
+func (p *Pipeline) OnRender(hook RenderHook) *Pipeline {
+	p.rendered = append(p.rendered, hook)
+	return p
+}
+
+// OnWrite registers a hook called with the changes to the output tree once it is written
+func (p *Pipeline) OnWrite(hook WriteHook) *Pipeline {
+	p.written = append(p.written, hook)
+	return p
+}
+
+// Schema returns the provider schema from the SchemaSource
+func (p *Pipeline) Schema() (providers.GetSchemaResponse, error) {
+	return p.source.GetSchema()
+}
+
+// Bootstrap writes the provider files which are not specific to a resource
+func (p *Pipeline) Bootstrap() error {
+	schema, err := p.Schema()
+	if err != nil {
+		return err
+	}
+	if err := p.bootstrapper(schema).Bootstrap(); err != nil {
+		return err
+	}
+	return p.overlays.Report(p.opts.Out, false)
+}
+
+// Types writes the type definitions of every selected resource
+func (p *Pipeline) Types() error {
+	st, err := p.schemaTranslator()
+	if err != nil {
+		return err
+	}
+	return st.WriteGeneratedTypes()
+}
+
+// Runtime writes the terraform-runtime methods of every selected resource
+func (p *Pipeline) Runtime() error {
+	st, err := p.schemaTranslator()
+	if err != nil {
+		return err
+	}
+	if err := st.WriteGeneratedRuntime(); err != nil {
+		return err
+	}
+	return p.overlays.Report(p.opts.Out, false)
+}
+
+// All bootstraps the provider, then writes the types and the runtime methods of
+// every selected resource, loading the schema only once. Nothing is written
+// unless every step succeeds. Only All warns about overlays matching no
+// generated file, since the other steps each generate part of the output tree.
+func (p *Pipeline) All() error {
+	schema, err := p.Schema()
+	if err != nil {
+		return err
+	}
+	bs := p.bootstrapper(schema)
+	st, err := p.schemaTranslator()
+	if err != nil {
+		return err
+	}
+	if err := provider.WriteGeneratedProvider(bs, st); err != nil {
+		return err
+	}
+	return p.overlays.Report(p.opts.Out, true)
+}
+
+// ManagedResource returns the ManagedResource of the named resource as it is
+// rendered, after the field overrides, optimizers and resource config are
+// applied, or as loaded from the IRDir.
+func (p *Pipeline) ManagedResource(name string) (*generator.ManagedResource, error) {
+	schema, err := p.Schema()
+	if err != nil {
+		return nil, err
+	}
+	return p.newSchemaTranslator(schema).WithOutput(ioutil.Discard).ManagedResource(name)
+}
+
+// ManagedResourceIR returns the ManagedResource of the named resource in its
+// serialized form, as json or yaml, see translate.MarshalIR.
+func (p *Pipeline) ManagedResourceIR(name, format string) ([]byte, error) {
+	mr, err := p.ManagedResource(name)
+	if err != nil {
+		return nil, err
+	}
+	return translate.MarshalIR(name, mr, format)
+}
+
+// ValidateConfig checks the include and exclude rules of the Config against the schema
+func (p *Pipeline) ValidateConfig() error {
+	schema, err := p.Schema()
+	if err != nil {
+		return err
+	}
+	return p.cfg.ValidateAgainstSchema(schema)
+}
+
+// SelectResources reports, in name order, whether each resource in the schema
+// is selected for generation by the Config, and why.
+func (p *Pipeline) SelectResources() ([]provider.ResourceSelection, error) {
+	schema, err := p.Schema()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(schema.ResourceTypes))
+	for name := range schema.ResourceTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return p.cfg.Selector().SelectAll(names), nil
+}
+
+func (p *Pipeline) bootstrapper(schema providers.GetSchemaResponse) *provider.Bootstrapper {
+	return provider.NewBootstrapper(p.cfg, p.tg, schema).
+		WithWriterOptions(p.opts.Writer).
+		WithFs(p.fs).
+		WithOutput(p.opts.Out).
+		WithOverlays(p.overlays).
+		WithHooks(p.hooks())
+}
+
+func (p *Pipeline) schemaTranslator() (*provider.SchemaTranslator, error) {
+	schema, err := p.Schema()
+	if err != nil {
+		return nil, err
+	}
+	p.printExcludeRules()
+	return p.newSchemaTranslator(schema), nil
+}
+
+func (p *Pipeline) newSchemaTranslator(schema providers.GetSchemaResponse) *provider.SchemaTranslator {
+	return provider.NewSchemaTranslator(p.cfg, p.opts.OutputDir, p.opts.OverlayDir, schema, p.tg).
+		WithJobs(p.opts.Jobs).
+		WithWriterOptions(p.opts.Writer).
+		WithPrune(p.opts.Prune).
+		WithVerify(p.opts.Verify).
+		WithFs(p.fs).
+		WithOutput(p.opts.Out).
+		WithProviderOverlays(p.overlays).
+		WithIRDir(p.opts.IRDir).
+		WithHooks(p.hooks())
+}
+
+func (p *Pipeline) printExcludeRules() {
+	if len(p.cfg.ExcludeResources) == 0 {
+		return
+	}
+	fmt.Fprintln(p.opts.Out, "Excluding resources matching the following rules from codegen:")
+	for _, r := range p.cfg.ExcludeResources {
+		if r.Reason != "" {
+			fmt.Fprintf(p.opts.Out, "%s: %s\n", r.Pattern, r.Reason)
+			continue
+		}
+		fmt.Fprintln(p.opts.Out, r.Pattern)
+	}
+}
+
+// hooks combines the registered hooks of each stage, which run in the order
+// they were registered, stopping at the first error.
+func (p *Pipeline) hooks() provider.Hooks {
+	h := provider.Hooks{}
+	if len(p.translated) > 0 {
+		h.Translated = chainResourceHooks(p.translated)
+	}
+	if len(p.optimized) > 0 {
+		h.Optimized = chainResourceHooks(p.optimized)
+	}
+	if len(p.rendered) > 0 {
+		rendered := p.rendered
+		h.Rendered = func(path string, content []byte) ([]byte, error) {
+			var err error
+			for _, hook := range rendered {
+				content, err = hook(path, content)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return content, nil
+		}
+	}
+	if len(p.written) > 0 {
+		written := p.written
+		h.Written = func(changes []provider.FileChange) error {
+			for _, hook := range written {
+				if err := hook(changes); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return h
+}
+
+func chainResourceHooks(hooks []ResourceHook) func(string, *generator.ManagedResource) error {
+	return func(name string, mr *generator.ManagedResource) error {
+		for _, hook := range hooks {
+			if err := hook(name, mr); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"sync"
+
+	"github.com/crossplane-contrib/terraform-runtime/pkg/client"
+	"github.com/hashicorp/terraform/providers"
+)
+
+// SchemaSource provides the schema of the terraform provider to generate from
+type SchemaSource interface {
+	GetSchema() (providers.GetSchemaResponse, error)
+}
+
+// SchemaSourceFunc adapts a function to a SchemaSource
+type SchemaSourceFunc func() (providers.GetSchemaResponse, error)
+
+func (f SchemaSourceFunc) GetSchema() (providers.GetSchemaResponse, error) {
+	return f()
+}
+
+// StaticSchema is a SchemaSource for a schema which has already been loaded
+func StaticSchema(schema providers.GetSchemaResponse) SchemaSource {
+	return SchemaSourceFunc(func() (providers.GetSchemaResponse, error) {
+		return schema, nil
+	})
+}
+
+// PluginSchema loads the schema from the provider plugin binary at pluginPath.
+// The plugin is started the first time the schema is needed, and the schema
+// is kept so that the plugin is only asked for it once.
+func PluginSchema(providerName, pluginPath string) SchemaSource {
+	var once sync.Once
+	var schema providers.GetSchemaResponse
+	var err error
+	return SchemaSourceFunc(func() (providers.GetSchemaResponse, error) {
+		once.Do(func() {
+			p, perr := client.NewGRPCProvider(providerName, pluginPath)
+			if perr != nil {
+				err = perr
+				return
+			}
+			schema = p.GetSchema()
+			if schema.Diagnostics.HasErrors() {
+				err = schema.Diagnostics.Err()
+			}
+		})
+		return schema, err
+	})
+}
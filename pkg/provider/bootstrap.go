@@ -2,12 +2,15 @@ package provider
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"os"
 	"path"
+	"sort"
 
 	"github.com/crossplane-contrib/terraform-provider-gen/pkg/template"
 	"github.com/hashicorp/terraform/providers"
+	"github.com/spf13/afero"
 )
 
 const (
@@ -26,9 +29,48 @@ type Bootstrapper struct {
 	cfg    Config
 	tg     template.TemplateGetter
 	schema providers.GetSchemaResponse
+	// writer is created by each run of Bootstrap
+	writer *FileWriter
+	opts   WriterOptions
+	fs     afero.Fs
+	out    io.Writer
+	hooks  Hooks
+	// overlays replace generated provider files, see WithOverlays
+	overlays *Overlays
 }
 
+// WithWriterOptions controls how Bootstrap writes files, eg as a dry run
+func (bs *Bootstrapper) WithWriterOptions(opts WriterOptions) *Bootstrapper {
+	bs.opts = opts
+	return bs
+}
+
+// Bootstrap writes the provider files which are not specific to a resource,
+// keeping a manifest in BasePath so that unchanged files are not rewritten.
+// Nothing is written unless every file is generated successfully.
 func (bs *Bootstrapper) Bootstrap() error {
+	fw, err := NewFileWriter(bs.fs, bs.cfg.BasePath, bs.opts)
+	if err != nil {
+		return err
+	}
+	if err := bs.stage(fw); err != nil {
+		return abort(bs.out, err, fw)
+	}
+	if err := fw.Close(); err != nil {
+		return err
+	}
+	fw.Report(bs.out)
+	return bs.hooks.written(fw.Changes())
+}
+
+// stage generates the provider files with fw, which is left for the caller to close
+func (bs *Bootstrapper) stage(fw *FileWriter) error {
+	fw.rendered = bs.hooks.Rendered
+	bs.writer = fw
+	return bs.bootstrap()
+}
+
+func (bs *Bootstrapper) bootstrap() error {
 	if err := bs.WriteMainGo(); err != nil {
 		return err
 	}
@@ -44,6 +86,12 @@ func (bs *Bootstrapper) Bootstrap() error {
 	if err := bs.WriteProviderIndex(); err != nil {
 		return err
 	}
+	if err := bs.WriteTemplateFiles(); err != nil {
+		return err
+	}
+	if err := bs.RunPlugins(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -78,9 +126,7 @@ func (bs *Bootstrapper) WriteProviderIndex() error {
 }
 
 func (bs *Bootstrapper) writeExecutedConfigTemplate(tplPath, outPath string) error {
-	dir := path.Dir(outPath)
-	err := os.MkdirAll(dir, 0700)
-	if err != nil {
+	if _, overlaid, err := bs.overlays.WriteIfOverlaid(bs.writer, bs.out, outPath); err != nil || overlaid {
 		return err
 	}
 	tpl, err := bs.tg.Get(tplPath)
@@ -88,18 +134,64 @@ func (bs *Bootstrapper) writeExecutedConfigTemplate(tplPath, outPath string) err
 		return err
 	}
 	buf := new(bytes.Buffer)
-	err = tpl.Execute(buf, bs.cfg)
+	err = tpl.Execute(buf, bs.templateData())
+	if err != nil {
+		return err
+	}
+	tplSource, err := templateSource(bs.tg, tplPath)
 	if err != nil {
 		return err
 	}
+	return bs.writeGenerated(outPath, "template "+tplPath, buf.Bytes(), tplSource)
+}
 
-	fh, err := os.OpenFile(outPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
-	defer fh.Close()
+// writeGenerated formats Go content, applies any patch overlays, and hands it
+// to the FileWriter along with a hash of the config, the selected resources,
+// the source of the generator, eg its template, and the patch overlays.
+// generatedBy names the generator in errors.
+func (bs *Bootstrapper) writeGenerated(outPath, generatedBy string, content []byte, source interface{}) error {
+	var err error
+	if isGoSource(outPath) {
+		content, err = formatGoSource(outPath, content)
+		if err != nil {
+			return fmt.Errorf("%s generated invalid Go in %s: %s", generatedBy, outPath, err)
+		}
+	}
+	content, patches, err := bs.overlays.Patch(outPath, content)
 	if err != nil {
 		return err
 	}
-	_, err = io.Copy(fh, buf)
-	return err
+	inputs, err := HashInputs(append([]interface{}{bs.cfg, bs.templateData().Resources, source}, patches...)...)
+	if err != nil {
+		return err
+	}
+	return bs.writer.WriteFile(outPath, inputs, content)
+}
+
+// providerTemplateData is passed to the provider file templates. It embeds
+// the Config so that templates can keep using its fields directly.
+type providerTemplateData struct {
+	Config
+	// Schema is the schema of the provider, including every resource type
+	Schema providers.GetSchemaResponse
+	// Resources are the names of the resources selected for generation, sorted
+	Resources []string
+}
+
+func (bs *Bootstrapper) templateData() providerTemplateData {
+	resources := make([]string, 0, len(bs.schema.ResourceTypes))
+	rs := bs.cfg.Selector()
+	for name := range bs.schema.ResourceTypes {
+		if rs.Select(name).Included {
+			resources = append(resources, name)
+		}
+	}
+	sort.Strings(resources)
+	return providerTemplateData{
+		Config:    bs.cfg,
+		Schema:    bs.schema,
+		Resources: resources,
+	}
 }
 
 func NewBootstrapper(cfg Config, tg template.TemplateGetter, schema providers.GetSchemaResponse) *Bootstrapper {
@@ -107,5 +199,7 @@ func NewBootstrapper(cfg Config, tg template.TemplateGetter, schema providers.Ge
 		cfg:    cfg,
 		tg:     tg,
 		schema: schema,
+		fs:     afero.NewOsFs(),
+		out:    os.Stdout,
 	}
 }
@@ -1,47 +1,169 @@
 package provider
 
 import (
-	"bytes"
-	"io"
 	"os"
+	"strings"
 
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/genplugin"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/optimize"
 	"sigs.k8s.io/yaml"
 )
 
 type Config struct {
-	ProviderVersion       string   `json:"provider-version"`
-	Name                  string   `json:"name"`
-	BasePath              string   `json:"base-path"`
-	RootPackage           string   `json:"root-package"`
-	PackagePath           string   `json:"package-path"`
-	BaseCRDVersion        string   `json:"base-crd-version"`
-	ProviderConfigVersion string   `json:"provider-config-version"`
-	APIGroup              string   `json:"api-group"`
-	ExcludeResources      []string `json:"exclude-resources"`
-	ExcludeResourceMap    map[string]bool
+	ProviderVersion       string                    `json:"provider-version"`
+	Name                  string                    `json:"name"`
+	BasePath              string                    `json:"base-path"`
+	RootPackage           string                    `json:"root-package"`
+	PackagePath           string                    `json:"package-path"`
+	BaseCRDVersion        string                    `json:"base-crd-version"`
+	ProviderConfigVersion string                    `json:"provider-config-version"`
+	APIGroup              string                    `json:"api-group"`
+	IncludeResources      []ResourceRule            `json:"include-resources"`
+	ExcludeResources      []ResourceRule            `json:"exclude-resources"`
+	Categories            []string                  `json:"categories"`
+	Scope                 string                    `json:"scope"`
+	Resources             map[string]ResourceConfig `json:"resources"`
+	// FieldOverrides are keyed by resource name and field path,
+	// eg aws_instance.root_block_device.volume_size
+	FieldOverrides map[string]optimize.FieldOverride `json:"field-overrides"`
+	// ResourceTemplates are rendered into the package of every resource along
+	// with its types, see TemplateFile
+	ResourceTemplates []TemplateFile `json:"resource-templates"`
+	// ProviderTemplates are rendered once when the provider is bootstrapped
+	ProviderTemplates []TemplateFile `json:"provider-templates"`
+	// Plugins are external generators run for the provider and for every
+	// resource, see the genplugin package
+	Plugins []genplugin.Plugin `json:"plugins"`
+}
+
+// ResourceConfig holds settings which apply to a single terraform resource,
+// keyed by the terraform resource name under Config.Resources.
+type ResourceConfig struct {
+	// Categories are appended to the provider-wide Config.Categories
+	Categories     []string              `json:"categories"`
+	PrinterColumns []PrinterColumnConfig `json:"printer-columns"`
+	// Scope overrides the provider-wide Config.Scope for this resource
+	Scope string `json:"scope"`
+}
+
+// PrinterColumnConfig adds a kubectl get column pointing at an atProvider field.
+// Field is a dot separated path of terraform attribute names, eg
+// root_block_device.volume_size. Type is inferred from the field when empty.
+type PrinterColumnConfig struct {
+	Name     string `json:"name"`
+	Field    string `json:"field"`
+	Type     string `json:"type"`
+	Priority int    `json:"priority"`
 }
 
 func (c Config) IsExcluded(resourceName string) bool {
-	_, ok := c.ExcludeResourceMap[resourceName]
-	return ok
+	return !c.Selection(resourceName).Included
 }
 
-func ConfigFromFile(path string) (Config, error) {
-	c := Config{}
-	fh, err := os.Open(path)
-	defer fh.Close()
-	if err != nil {
-		return c, err
+// Selection applies the include-resources and exclude-resources rules to
+// the named resource. See ResourceSelector for the precedence rules.
+func (c Config) Selection(resourceName string) ResourceSelection {
+	return c.Selector().Select(resourceName)
+}
+
+// Selector builds a ResourceSelector from the current config rules, so that
+// rules changed after ParseConfig are honoured. Invalid patterns are dropped,
+// Validate reports them. Build it once to select many resources.
+func (c Config) Selector() *ResourceSelector {
+	rs, _ := NewResourceSelector(c.IncludeResources, c.ExcludeResources)
+	return rs
+}
+
+// ResourceConfig returns the resource-specific config for the named resource,
+// or an empty ResourceConfig if there isn't one.
+func (c Config) ResourceConfig(resourceName string) ResourceConfig {
+	return c.Resources[resourceName]
+}
+
+// ScopeFor returns the kubernetes scope (Cluster or Namespaced) of the named
+// resource. The resource setting wins over the provider-wide setting, and
+// resources are cluster-scoped when neither is set.
+func (c Config) ScopeFor(resourceName string) string {
+	if s := c.ResourceConfig(resourceName).Scope; s != "" {
+		return s
+	}
+	if c.Scope != "" {
+		return c.Scope
 	}
-	buf := new(bytes.Buffer)
-	_, err = io.Copy(buf, fh)
-	err = yaml.Unmarshal(buf.Bytes(), &c)
+	return generator.ResourceScopeCluster
+}
+
+// FieldOverridesFor returns the field overrides for the named resource,
+// keyed by the field path with the resource name prefix removed.
+func (c Config) FieldOverridesFor(resourceName string) map[string]optimize.FieldOverride {
+	overrides := make(map[string]optimize.FieldOverride)
+	prefix := resourceName + "."
+	for key, fo := range c.FieldOverrides {
+		if strings.HasPrefix(key, prefix) {
+			overrides[strings.TrimPrefix(key, prefix)] = fo
+		}
+	}
+	return overrides
+}
+
+// CategoriesFor merges the provider-wide categories with those configured
+// for the named resource, dropping duplicates while preserving order.
+func (c Config) CategoriesFor(resourceName string) []string {
+	seen := make(map[string]bool)
+	categories := make([]string, 0)
+	all := append(append([]string{}, c.Categories...), c.ResourceConfig(resourceName).Categories...)
+	for _, cat := range all {
+		if seen[cat] {
+			continue
+		}
+		seen[cat] = true
+		categories = append(categories, cat)
+	}
+	return categories
+}
+
+// resourceSlice is the part of the config which affects the files generated
+// for the named resource. It is hashed to decide whether those files are stale.
+func (c Config) resourceSlice(resourceName string) interface{} {
+	return struct {
+		Name                  string
+		RootPackage           string
+		PackagePath           string
+		BaseCRDVersion        string
+		ProviderConfigVersion string
+		APIGroup              string
+		Categories            []string
+		Scope                 string
+		Resource              ResourceConfig
+		FieldOverrides        map[string]optimize.FieldOverride
+	}{
+		Name:                  c.Name,
+		RootPackage:           c.RootPackage,
+		PackagePath:           c.PackagePath,
+		BaseCRDVersion:        c.BaseCRDVersion,
+		ProviderConfigVersion: c.ProviderConfigVersion,
+		APIGroup:              c.APIGroup,
+		Categories:            c.CategoriesFor(resourceName),
+		Scope:                 c.ScopeFor(resourceName),
+		Resource:              c.ResourceConfig(resourceName),
+		FieldOverrides:        c.FieldOverridesFor(resourceName),
+	}
+}
+
+// ConfigFromFile reads, merges and validates the yaml config at the given path,
+// applying any key=value overrides. See LoadConfig for details.
+func ConfigFromFile(path string, overrides ...string) (Config, error) {
+	return LoadConfig(path, overrides, os.LookupEnv)
+}
+
+// ParseConfig strictly decodes a yaml config, rejecting unknown keys,
+// and then validates the result with Config.Validate.
+func ParseConfig(b []byte) (Config, error) {
+	c := Config{}
+	err := yaml.UnmarshalStrict(b, &c)
 	if err != nil {
 		return c, err
 	}
-	c.ExcludeResourceMap = make(map[string]bool)
-	for _, er := range c.ExcludeResources {
-		c.ExcludeResourceMap[er] = true
-	}
-	return c, nil
+	return c, c.Validate()
 }
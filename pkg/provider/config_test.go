@@ -0,0 +1,242 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+)
+
+func TestCategoriesFor(t *testing.T) {
+	cfg := Config{
+		Categories: []string{"crossplane", "managed", "aws"},
+		Resources: map[string]ResourceConfig{
+			"aws_instance": {Categories: []string{"ec2", "aws"}},
+		},
+	}
+	actual := cfg.CategoriesFor("aws_instance")
+	expected := []string{"crossplane", "managed", "aws", "ec2"}
+	if len(actual) != len(expected) {
+		t.Fatalf("Unexpected categories. expected=%v, actual=%v", expected, actual)
+	}
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Errorf("Unexpected categories. expected=%v, actual=%v", expected, actual)
+			break
+		}
+	}
+}
+
+func TestScopeFor(t *testing.T) {
+	cfg := Config{}
+	if s := cfg.ScopeFor("aws_instance"); s != generator.ResourceScopeCluster {
+		t.Errorf("Expected default scope to be Cluster, saw=%s", s)
+	}
+	cfg.Scope = generator.ResourceScopeNamespaced
+	cfg.Resources = map[string]ResourceConfig{
+		"aws_iam_role": {Scope: generator.ResourceScopeCluster},
+	}
+	if s := cfg.ScopeFor("aws_instance"); s != generator.ResourceScopeNamespaced {
+		t.Errorf("Expected provider-wide scope to apply, saw=%s", s)
+	}
+	if s := cfg.ScopeFor("aws_iam_role"); s != generator.ResourceScopeCluster {
+		t.Errorf("Expected per-resource scope to win, saw=%s", s)
+	}
+}
+
+func TestSelectionFollowsRuleChanges(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`name: aws
+package-path: github.com/crossplane-contrib/provider-terraform-aws/generated/resources
+base-crd-version: v1alpha1
+provider-config-version: v1alpha1
+exclude-resources:
+ - aws_s3_bucket
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.IsExcluded("aws_s3_bucket") || cfg.IsExcluded("aws_instance") {
+		t.Fatalf("Expected only aws_s3_bucket to be excluded by the parsed rules")
+	}
+	cfg.ExcludeResources = []ResourceRule{{Pattern: "aws_instance"}}
+	if cfg.IsExcluded("aws_s3_bucket") || !cfg.IsExcluded("aws_instance") {
+		t.Errorf("Expected the exclude rules changed after ParseConfig to apply")
+	}
+}
+
+func TestParseConfigRejectsUnknownKeys(t *testing.T) {
+	cfg := `name: aws
+package-path: github.com/crossplane-contrib/provider-terraform-aws/generated/resources
+base-crd-version: v1alpha1
+provider-config-version: v1alpha1
+exclude-resource:
+ - aws_s3_bucket
+`
+	_, err := ParseConfig([]byte(cfg))
+	if err == nil {
+		t.Errorf("Expected ParseConfig to reject the unknown key 'exclude-resource'")
+	}
+}
+
+func TestConfigValidateReportsAllErrors(t *testing.T) {
+	cfg := Config{
+		Scope:            "Global",
+		ExcludeResources: []ResourceRule{{Pattern: "aws_s3_bucket"}, {Pattern: "aws_s3_bucket"}},
+	}
+	err := cfg.Validate()
+	me, ok := err.(generator.MultiError)
+	if !ok {
+		t.Fatalf("Could not type assert Validate error to MultiError, err=%v", err)
+	}
+	expected := []error{
+		InvalidConfigNameEmpty,
+		InvalidConfigPackagePathEmpty,
+		InvalidConfigBaseCRDVersionEmpty,
+		InvalidConfigProviderConfigVersionEmpty,
+	}
+	for _, e := range expected {
+		found := false
+		for _, actual := range me.Errors() {
+			if actual == e {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Did not find expected validation error=%v", e)
+		}
+	}
+	// 4 missing keys + invalid scope + duplicate exclude entry
+	if len(me.Errors()) != 6 {
+		t.Errorf("Expected 6 validation errors, saw %d:\n%s", len(me.Errors()), me.Error())
+	}
+}
+
+func TestFieldOverridesFor(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+name: aws
+package-path: github.com/crossplane-contrib/provider-terraform-aws
+base-crd-version: v1alpha1
+provider-config-version: v1alpha1
+field-overrides:
+  aws_instance.root_block_device.volume_size:
+    type: int32
+  aws_instance.tags:
+    ignore: true
+  aws_instance_profile.arn:
+    location: status
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing config: %s", err)
+	}
+	overrides := cfg.FieldOverridesFor("aws_instance")
+	if len(overrides) != 2 {
+		t.Fatalf("Expected 2 overrides for aws_instance, saw %d", len(overrides))
+	}
+	if overrides["root_block_device.volume_size"].Type != "int32" {
+		t.Errorf("Expected root_block_device.volume_size to be retyped to int32, saw %v", overrides["root_block_device.volume_size"])
+	}
+	if !overrides["tags"].Ignore {
+		t.Errorf("Expected tags to be ignored")
+	}
+}
+
+func TestFieldOverridesValidation(t *testing.T) {
+	_, err := ParseConfig([]byte(`
+name: aws
+package-path: github.com/crossplane-contrib/provider-terraform-aws
+base-crd-version: v1alpha1
+provider-config-version: v1alpha1
+field-overrides:
+  aws_instance:
+    ignore: true
+  aws_instance.arn:
+    location: metadata
+  aws_instance.id:
+    type: decimal
+  aws_instance.tags:
+    ignore: true
+    immutable: true
+`))
+	me, ok := err.(generator.MultiError)
+	if !ok {
+		t.Fatalf("Could not type assert ParseConfig error to MultiError, err=%v", err)
+	}
+	if len(me.Errors()) != 4 {
+		t.Errorf("Expected 4 validation errors, saw %d:\n%s", len(me.Errors()), me.Error())
+	}
+}
+
+func TestTemplateFilesValidation(t *testing.T) {
+	_, err := ParseConfig([]byte(`
+name: aws
+package-path: github.com/crossplane-contrib/provider-terraform-aws
+base-crd-version: v1alpha1
+provider-config-version: v1alpha1
+resource-templates:
+- template: examples/example.yaml.tmpl
+  output: examples/example.yaml
+- template: webhook.go.tmpl
+- output: examples/./example.yaml
+- template: types.go.tmpl
+  output: types.go
+- template: escape.tmpl
+  output: ../escape.go
+provider-templates:
+- template: README.md.tmpl
+  output: README.md
+- template: main.go.tmpl
+  output: cmd/provider/main.go
+- template: types.go.tmpl
+  output: /generated/types.go
+`))
+	me, ok := err.(generator.MultiError)
+	if !ok {
+		t.Fatalf("Could not type assert ParseConfig error to MultiError, err=%v", err)
+	}
+	expected := []string{
+		"resource-templates[1]: output is required",
+		"resource-templates[2]: template is required",
+		"resource-templates[2]: output examples/./example.yaml is already written by resource-templates[0]",
+		"resource-templates[3]: output types.go is already written by the generator",
+		"resource-templates[4]: output ../escape.go must be a relative path within its directory",
+		"provider-templates[1]: output cmd/provider/main.go is already written by the generator",
+		"provider-templates[2]: output /generated/types.go must be a relative path within its directory",
+	}
+	if len(me.Errors()) != len(expected) {
+		t.Errorf("Expected %d validation errors, saw %d:\n%s", len(expected), len(me.Errors()), me.Error())
+	}
+	for _, e := range expected {
+		if !strings.Contains(me.Error(), e) {
+			t.Errorf("Expected validation error %q, saw:\n%s", e, me.Error())
+		}
+	}
+}
+
+func TestPluginsValidation(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+name: aws
+package-path: github.com/crossplane-contrib/provider-terraform-aws
+base-crd-version: v1alpha1
+provider-config-version: v1alpha1
+plugins:
+- command: terraform-provider-gen-examples
+  args: [--verbose]
+  parameter: minimal
+- name: docs
+`))
+	me, ok := err.(generator.MultiError)
+	if !ok {
+		t.Fatalf("Could not type assert ParseConfig error to MultiError, err=%v, cfg=%v", err, cfg)
+	}
+	if len(me.Errors()) != 1 || me.Errors()[0].Error() != "plugins[1]: command is required" {
+		t.Errorf("Expected only a missing command error, saw:\n%s", me.Error())
+	}
+}
+
+func TestProviderConfigsAreValid(t *testing.T) {
+	for _, p := range []string{"../../provider-configs/aws.yaml", "../../provider-configs/vsphere.yaml"} {
+		if _, err := ConfigFromFile(p); err != nil {
+			t.Errorf("Unexpected error loading %s: %s", p, err)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// snippetContext is the number of lines shown either side of a syntax error
+const snippetContext = 3
+
+// formatGoSource parses generated Go source, removes imports which are never
+// referenced and formats the result the way gofmt does. A syntax error is
+// returned as a *GoSourceError, which shows the offending snippet.
+func formatGoSource(filename string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, newGoSourceError(src, err)
+	}
+	return format.Source(pruneImports(fset, f, src))
+}
+
+// isGoSource is true for paths the generator formats before writing
+func isGoSource(p string) bool {
+	return strings.HasSuffix(p, ".go")
+}
+
+// pruneImports removes the lines of imports whose package name is never used as
+// the qualifier of a selector, along with import declarations left empty. Like
+// goimports, it assumes that a package is named after the last element of its
+// import path, ignoring a major version suffix, and keeps imports where that
+// guess can't be made, along with blank and dot imports.
+func pruneImports(fset *token.FileSet, f *ast.File, src []byte) []byte {
+	used := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			// identifiers which resolve to a declaration in the file aren't packages
+			if id, ok := sel.X.(*ast.Ident); ok && id.Obj == nil {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	// byte ranges of src to remove, in source order
+	cuts := make([][2]int, 0)
+	for _, d := range f.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			continue
+		}
+		specCuts := make([][2]int, 0)
+		for _, s := range gd.Specs {
+			imp := s.(*ast.ImportSpec)
+			name, ok := importName(imp)
+			if !ok || used[name] {
+				continue
+			}
+			var from, to ast.Node = imp, imp
+			if imp.Doc != nil {
+				from = imp.Doc
+			}
+			if imp.Comment != nil {
+				to = imp.Comment
+			}
+			specCuts = append(specCuts, lineRange(fset, src, from.Pos(), to.End()))
+		}
+		if len(specCuts) == len(gd.Specs) && len(gd.Specs) > 0 {
+			var from ast.Node = gd
+			if gd.Doc != nil {
+				from = gd.Doc
+			}
+			cuts = append(cuts, lineRange(fset, src, from.Pos(), gd.End()))
+			continue
+		}
+		cuts = append(cuts, specCuts...)
+	}
+	if len(cuts) == 0 {
+		return src
+	}
+	pruned := make([]byte, 0, len(src))
+	last := 0
+	for _, c := range cuts {
+		pruned = append(pruned, src[last:c[0]]...)
+		last = c[1]
+	}
+	return append(pruned, src[last:]...)
+}
+
+// lineRange widens the byte range from pos to end to whole lines, when nothing
+// else shares those lines
+func lineRange(fset *token.FileSet, src []byte, pos, end token.Pos) [2]int {
+	from, to := fset.Position(pos).Offset, fset.Position(end).Offset
+	start := bytes.LastIndexByte(src[:from], '\n') + 1
+	if len(bytes.TrimSpace(src[start:from])) == 0 {
+		from = start
+	}
+	if i := bytes.IndexByte(src[to:], '\n'); i >= 0 && len(bytes.TrimSpace(src[to:to+i])) == 0 {
+		to += i + 1
+	}
+	return [2]int{from, to}
+}
+
+// importName returns the name an import is referred to by, and false for blank
+// and dot imports or when the name can't be determined from the path.
+func importName(imp *ast.ImportSpec) (string, bool) {
+	if imp.Name != nil {
+		switch imp.Name.Name {
+		case "_", ".":
+			return "", false
+		}
+		return imp.Name.Name, true
+	}
+	p, err := strconv.Unquote(imp.Path.Value)
+	if err != nil {
+		return "", false
+	}
+	name := path.Base(p)
+	if isMajorVersion(name) && path.Dir(p) != "." {
+		name = path.Base(path.Dir(p))
+	}
+	// gopkg.in/yaml.v2 is package yaml
+	if i := strings.Index(name, ".v"); i > 0 && isMajorVersion(name[i+1:]) {
+		name = name[:i]
+	}
+	if !token.IsIdentifier(name) {
+		return "", false
+	}
+	return name, true
+}
+
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(s[1:])
+	return err == nil
+}
+
+// GoSourceError is a syntax error in generated Go source
+type GoSourceError struct {
+	Line    int
+	Column  int
+	Msg     string
+	Snippet string
+}
+
+func newGoSourceError(src []byte, err error) error {
+	list, ok := err.(scanner.ErrorList)
+	if !ok || len(list) == 0 {
+		return err
+	}
+	first := list[0]
+	return &GoSourceError{
+		Line:    first.Pos.Line,
+		Column:  first.Pos.Column,
+		Msg:     first.Msg,
+		Snippet: sourceSnippet(src, first.Pos.Line, first.Pos.Column),
+	}
+}
+
+func (e *GoSourceError) Error() string {
+	return fmt.Sprintf("syntax error at line %d, column %d: %s\n%s", e.Line, e.Column, e.Msg, e.Snippet)
+}
+
+// sourceSnippet returns the lines around line, numbered, with a caret under column
+func sourceSnippet(src []byte, line, column int) string {
+	lines := strings.Split(string(src), "\n")
+	start, end := line-snippetContext, line+snippetContext
+	if start < 1 {
+		start = 1
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	width := len(strconv.Itoa(end))
+	buf := new(bytes.Buffer)
+	for n := start; n <= end; n++ {
+		fmt.Fprintf(buf, "%*d | %s\n", width, n, lines[n-1])
+		if n == line && column > 0 {
+			fmt.Fprintf(buf, "%*s | %s^\n", width, "", caretIndent(lines[n-1], column))
+		}
+	}
+	return buf.String()
+}
+
+// caretIndent returns whitespace reaching column, keeping tabs so the caret
+// lines up with the source line above it
+func caretIndent(line string, column int) string {
+	indent := make([]byte, 0, column)
+	for i := 0; i < column-1 && i < len(line); i++ {
+		if line[i] == '\t' {
+			indent = append(indent, '\t')
+		} else {
+			indent = append(indent, ' ')
+		}
+	}
+	return string(indent)
+}
@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatGoSource(t *testing.T) {
+	// the strings parameter shadows the strings package, leaving the import unused
+	src := `package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+	_ "embed"
+
+	ctwhy "github.com/crossplane-contrib/terraform-runtime/pkg/plugin/cty"
+	"github.com/zclconf/go-cty/cty"
+	"gopkg.in/yaml.v2"
+)
+
+func encode(strings []string) cty.Value {
+        vals := make([]cty.Value, 0)
+  for _, s := range strings {
+		vals = append(vals, cty.StringVal(fmt.Sprint(s)))
+	}
+	return cty.ListVal(vals)
+}
+`
+	expected := `package v1alpha1
+
+import (
+	_ "embed"
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func encode(strings []string) cty.Value {
+	vals := make([]cty.Value, 0)
+	for _, s := range strings {
+		vals = append(vals, cty.StringVal(fmt.Sprint(s)))
+	}
+	return cty.ListVal(vals)
+}
+`
+	formatted, err := formatGoSource("encode.go", []byte(src))
+	if err != nil {
+		t.Fatalf("Unexpected error formatting source: %s", err)
+	}
+	if string(formatted) != expected {
+		t.Errorf("Unexpected formatted source, expected:\n%s\nsaw:\n%s", expected, string(formatted))
+	}
+}
+
+func TestFormatGoSourceSyntaxError(t *testing.T) {
+	src := "package v1alpha1\n\nfunc a() {\n\tif x {\n\t\treturn\n\t}}\n}\n"
+	_, err := formatGoSource("compare.go", []byte(src))
+	serr, ok := err.(*GoSourceError)
+	if !ok {
+		t.Fatalf("Expected a *GoSourceError, saw %v", err)
+	}
+	if serr.Line != 7 {
+		t.Errorf("Expected the error to be on line 7, saw %d", serr.Line)
+	}
+	if !strings.Contains(serr.Error(), "7 | }\n") || !strings.Contains(serr.Error(), "6 | \t}}\n") {
+		t.Errorf("Expected the error to show the offending snippet, saw:\n%s", serr.Error())
+	}
+}
@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"io"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	"github.com/spf13/afero"
+)
+
+// Hooks are called between the stages of generation: translating a resource
+// schema into a ManagedResource, optimizing it, rendering each file and writing
+// the output tree. Any of them may be nil. The resource and file hooks are
+// called concurrently when more than one resource is generated at a time.
+type Hooks struct {
+	// Translated is called with each ManagedResource as translated from its schema
+	Translated func(name string, mr *generator.ManagedResource) error
+	// Optimized is called with each ManagedResource once field overrides and
+	// the optimizers have been applied, just before it is rendered
+	Optimized func(name string, mr *generator.ManagedResource) error
+	// Rendered is called with the content of each file before it is written,
+	// and returns the content to write in its place
+	Rendered func(path string, content []byte) ([]byte, error)
+	// Written is called with every change made to the output tree, or for a
+	// dry run every change which would have been made, once output is complete
+	Written func(changes []FileChange) error
+}
+
+// WithHooks sets the hooks called between the stages of generation
+func (st *SchemaTranslator) WithHooks(hooks Hooks) *SchemaTranslator {
+	st.hooks = hooks
+	return st
+}
+
+// WithFs sets the filesystem that output is written to and overlays are read from
+func (st *SchemaTranslator) WithFs(fs afero.Fs) *SchemaTranslator {
+	st.fs = fs
+	return st
+}
+
+// WithOutput sets where progress messages and the report of written files go
+func (st *SchemaTranslator) WithOutput(w io.Writer) *SchemaTranslator {
+	st.out = w
+	return st
+}
+
+// WithHooks sets the hooks called as files are rendered and written
+func (bs *Bootstrapper) WithHooks(hooks Hooks) *Bootstrapper {
+	bs.hooks = hooks
+	return bs
+}
+
+// WithFs sets the filesystem that the provider files are written to
+func (bs *Bootstrapper) WithFs(fs afero.Fs) *Bootstrapper {
+	bs.fs = fs
+	return bs
+}
+
+// WithOutput sets where progress messages and the report of written files go
+func (bs *Bootstrapper) WithOutput(w io.Writer) *Bootstrapper {
+	bs.out = w
+	return bs
+}
+
+func (h Hooks) translated(name string, mr *generator.ManagedResource) error {
+	if h.Translated == nil {
+		return nil
+	}
+	return h.Translated(name, mr)
+}
+
+func (h Hooks) optimized(name string, mr *generator.ManagedResource) error {
+	if h.Optimized == nil {
+		return nil
+	}
+	return h.Optimized(name, mr)
+}
+
+func (h Hooks) written(changes []FileChange) error {
+	if h.Written == nil {
+		return nil
+	}
+	return h.Written(changes)
+}
@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/translate"
+	"github.com/spf13/afero"
+)
+
+// irExts are the extensions of the IR files of a resource, see WithIRDir
+var irExts = []string{".yaml", ".json"}
+
+// WithIRDir loads the ManagedResource of a resource from dir/<resource>.yaml
+// or dir/<resource>.json, eg as written by translate ir dump, in place of
+// translating and optimizing its schema. The categories, scope and printer
+// columns of the resource config are applied to the loaded ManagedResource as
+// usual, and the Translated hook is not called for it. Resources without an IR
+// file are translated as usual.
+func (st *SchemaTranslator) WithIRDir(dir string) *SchemaTranslator {
+	st.irDir = dir
+	return st
+}
+
+// ManagedResource returns the ManagedResource which is rendered for the named
+// resource, with field overrides, optimizers and the resource config applied,
+// or as loaded from the IR directory with the resource config applied. Nothing
+// is written.
+func (st *SchemaTranslator) ManagedResource(name string) (*generator.ManagedResource, error) {
+	if _, ok := st.schema.ResourceTypes[name]; !ok {
+		return nil, fmt.Errorf("resource %s is not in the provider schema", name)
+	}
+	pt := st.packageTranslator(name, ioutil.Discard, nil)
+	return st.managedResource(pt, name)
+}
+
+// loadIR returns the ManagedResource in the IR file of the named resource,
+// along with the content of the file, or nil when there is no IR file.
+func (st *SchemaTranslator) loadIR(name string) (*generator.ManagedResource, []byte, error) {
+	if st.irDir == "" {
+		return nil, nil, nil
+	}
+	found := make([]string, 0)
+	for _, ext := range irExts {
+		p := path.Join(st.irDir, name+ext)
+		if ok, err := afero.Exists(st.fs, p); err != nil {
+			return nil, nil, err
+		} else if ok {
+			found = append(found, p)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, nil, nil
+	case 1:
+	default:
+		return nil, nil, fmt.Errorf("found more than one IR file for %s: %v", name, found)
+	}
+	b, err := afero.ReadFile(st.fs, found[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	ir, err := translate.UnmarshalIR(b)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot load IR from %s: %s", found[0], err)
+	}
+	if ir.Resource != name {
+		return nil, nil, fmt.Errorf("IR file %s is for resource %s", found[0], ir.Resource)
+	}
+	if err := ir.ManagedResource.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", found[0], err)
+	}
+	fmt.Fprintf(st.out, "Loaded the IR of %s from %s\n", name, found[0])
+	return ir.ManagedResource, b, nil
+}
@@ -0,0 +1,220 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	"sigs.k8s.io/yaml"
+)
+
+// ExtendsKey names the base config a config file is layered on top of.
+// Relative paths are resolved against the directory of the extending file.
+const ExtendsKey = "extends"
+
+// envRefPattern matches ${NAME} and ${NAME:-default} references. A reference
+// can be escaped by doubling the dollar sign, eg $${NAME}.
+var envRefPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// configLayer is the generic form of a config file, before it is decoded into a Config
+type configLayer map[string]interface{}
+
+// loadConfigLayer reads the config at the given path, recursively merging it over
+// the config it extends. Environment variables are interpolated in each file
+// before merging so that a base config can be shared between environments.
+func loadConfigLayer(path string, lookup func(string) (string, bool), seen []string) (configLayer, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range seen {
+		if s == abs {
+			return nil, fmt.Errorf("%s: extends cycle detected: %s", path, strings.Join(append(seen, abs), " -> "))
+		}
+	}
+	seen = append(seen, abs)
+
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	buf := new(bytes.Buffer)
+	if _, err = io.Copy(buf, fh); err != nil {
+		return nil, err
+	}
+	layer := make(configLayer)
+	if err = yaml.UnmarshalStrict(buf.Bytes(), &layer); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	interpolated, err := interpolateEnv(map[string]interface{}(layer), lookup)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	layer = configLayer(interpolated.(map[string]interface{}))
+
+	ext, ok := layer[ExtendsKey]
+	if !ok {
+		return layer, nil
+	}
+	delete(layer, ExtendsKey)
+	extPath, ok := ext.(string)
+	if !ok || extPath == "" {
+		return nil, fmt.Errorf("%s: %s must be a path to another config file", path, ExtendsKey)
+	}
+	if !filepath.IsAbs(extPath) {
+		extPath = filepath.Join(filepath.Dir(path), extPath)
+	}
+	base, err := loadConfigLayer(extPath, lookup, seen)
+	if err != nil {
+		return nil, err
+	}
+	return mergeConfigLayers(base, layer), nil
+}
+
+// mergeConfigLayers returns the result of layering overlay on top of base.
+// Maps are merged key by key, any other value in the overlay, including lists,
+// replaces the value in the base.
+func mergeConfigLayers(base, overlay map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overlay {
+		bm, baseIsMap := merged[k].(map[string]interface{})
+		om, overlayIsMap := v.(map[string]interface{})
+		if baseIsMap && overlayIsMap {
+			merged[k] = mergeConfigLayers(bm, om)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// interpolateEnv replaces environment variable references in every string value.
+// Referencing an unset variable without a default is an error, every missing
+// variable is reported in the returned generator.MultiError.
+func interpolateEnv(v interface{}, lookup func(string) (string, bool)) (interface{}, error) {
+	fail := generator.NewMultiError("failed to interpolate environment variables:")
+	var walk func(v interface{}) interface{}
+	walk = func(v interface{}) interface{} {
+		switch t := v.(type) {
+		case string:
+			return envRefPattern.ReplaceAllStringFunc(t, func(ref string) string {
+				if strings.HasPrefix(ref, "$$") {
+					return ref[1:]
+				}
+				m := envRefPattern.FindStringSubmatch(ref)
+				if val, ok := lookup(m[1]); ok {
+					return val
+				}
+				if m[2] != "" {
+					return m[3]
+				}
+				fail.Append(fmt.Errorf("environment variable %s is not set", m[1]))
+				return ref
+			})
+		case map[string]interface{}:
+			result := make(map[string]interface{})
+			// walk keys in order so that errors are reported deterministically
+			keys := make([]string, 0, len(t))
+			for k := range t {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				result[k] = walk(t[k])
+			}
+			return result
+		case []interface{}:
+			result := make([]interface{}, len(t))
+			for i, e := range t {
+				result[i] = walk(e)
+			}
+			return result
+		}
+		return v
+	}
+	result := walk(v)
+	if len(fail.Errors()) > 0 {
+		return nil, fail
+	}
+	return result, nil
+}
+
+// applyConfigOverride sets a single key=value override on the layer. The key is a
+// dot separated path into the config, eg resources.aws_instance.scope, and the
+// value is parsed as yaml, so lists and maps can be given in flow style.
+func applyConfigOverride(layer map[string]interface{}, override string) error {
+	parts := strings.SplitN(override, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("invalid override %q, expected key=value", override)
+	}
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(parts[1]), &value); err != nil {
+		return fmt.Errorf("invalid value in override %q: %s", override, err)
+	}
+	keys := strings.Split(parts[0], ".")
+	current := layer
+	for i, k := range keys[:len(keys)-1] {
+		next, ok := current[k]
+		if !ok || next == nil {
+			next = make(map[string]interface{})
+			current[k] = next
+		}
+		m, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid override %q, %s is not a map", override, strings.Join(keys[:i+1], "."))
+		}
+		current = m
+	}
+	current[keys[len(keys)-1]] = value
+	return nil
+}
+
+// LoadConfig builds a Config from the layered config file at path. Environment
+// variable references are interpolated, the chain of extends files is merged,
+// and then each override of the form key=value is applied before the
+// result is strictly decoded and validated.
+func LoadConfig(path string, overrides []string, lookup func(string) (string, bool)) (Config, error) {
+	layer, err := loadConfigLayer(path, lookup, nil)
+	if err != nil {
+		return Config{}, err
+	}
+	for _, o := range overrides {
+		if err := applyConfigOverride(layer, o); err != nil {
+			return Config{}, err
+		}
+	}
+	b, err := json.Marshal(layer)
+	if err != nil {
+		return Config{}, err
+	}
+	c, err := ParseConfig(b)
+	if err != nil {
+		return c, errorInFile(path, err)
+	}
+	return c, nil
+}
+
+// errorInFile prefixes err with the path of the config file it was found in,
+// keeping a generator.MultiError so that its errors can still be listed.
+func errorInFile(path string, err error) error {
+	me, ok := err.(generator.MultiError)
+	if !ok {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	fail := generator.NewMultiError(fmt.Sprintf("%s: invalid config:", path))
+	for _, e := range me.Errors() {
+		fail.Append(e)
+	}
+	return fail
+}
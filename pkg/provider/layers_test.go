@@ -0,0 +1,159 @@
+package provider
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+)
+
+const baseLayerFixture = `
+name: aws
+package-path: github.com/crossplane-contrib/provider-terraform-aws/generated/resources
+base-crd-version: v1alpha1
+provider-config-version: v1alpha1
+base-path: ${PROVIDER_PATH:-/default/path}
+categories:
+ - crossplane
+ - aws
+resources:
+  aws_instance:
+    scope: Namespaced
+    categories: [ec2]
+`
+
+const devLayerFixture = `
+extends: base.yaml
+base-path: ${HOME_DIR}/provider-terraform-aws
+categories:
+ - aws
+resources:
+  aws_instance:
+    categories: [compute]
+exclude-resources:
+ - /^aws_\w+$${SUFFIX}/
+`
+
+func writeLayerFixtures(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "layers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func fakeEnv(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+func TestLoadConfigExtends(t *testing.T) {
+	dir := writeLayerFixtures(t, map[string]string{"base.yaml": baseLayerFixture, "dev.yaml": devLayerFixture})
+	defer os.RemoveAll(dir)
+
+	cfg, err := LoadConfig(filepath.Join(dir, "dev.yaml"), nil, fakeEnv(map[string]string{"HOME_DIR": "/home/dev"}))
+	if err != nil {
+		t.Fatalf("Unexpected error loading layered config: %s", err)
+	}
+	if cfg.BasePath != "/home/dev/provider-terraform-aws" {
+		t.Errorf("Expected base-path to be interpolated and overridden, saw %s", cfg.BasePath)
+	}
+	if cfg.Name != "aws" {
+		t.Errorf("Expected name to be inherited from the base config, saw %s", cfg.Name)
+	}
+	if len(cfg.Categories) != 1 || cfg.Categories[0] != "aws" {
+		t.Errorf("Expected lists to be replaced rather than merged, saw %v", cfg.Categories)
+	}
+	rc := cfg.ResourceConfig("aws_instance")
+	if rc.Scope != generator.ResourceScopeNamespaced {
+		t.Errorf("Expected resources.aws_instance.scope to be merged from the base config, saw %s", rc.Scope)
+	}
+	if len(rc.Categories) != 1 || rc.Categories[0] != "compute" {
+		t.Errorf("Expected resources.aws_instance.categories to come from the extending config, saw %v", rc.Categories)
+	}
+	if cfg.ExcludeResources[0].Pattern != `/^aws_\w+${SUFFIX}/` {
+		t.Errorf("Expected escaped reference to be left uninterpolated, saw %s", cfg.ExcludeResources[0].Pattern)
+	}
+}
+
+func TestLoadConfigDefaultsAndOverrides(t *testing.T) {
+	dir := writeLayerFixtures(t, map[string]string{"base.yaml": baseLayerFixture})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "base.yaml")
+	cfg, err := LoadConfig(path, nil, fakeEnv(nil))
+	if err != nil {
+		t.Fatalf("Unexpected error loading config: %s", err)
+	}
+	if cfg.BasePath != "/default/path" {
+		t.Errorf("Expected base-path to use the default value, saw %s", cfg.BasePath)
+	}
+
+	overrides := []string{"base-path=/tmp/out", "resources.aws_instance.scope=Cluster", "categories=[one, two]"}
+	cfg, err = LoadConfig(path, overrides, fakeEnv(nil))
+	if err != nil {
+		t.Fatalf("Unexpected error loading config with overrides: %s", err)
+	}
+	if cfg.BasePath != "/tmp/out" {
+		t.Errorf("Expected base-path to be overridden, saw %s", cfg.BasePath)
+	}
+	if cfg.ScopeFor("aws_instance") != generator.ResourceScopeCluster {
+		t.Errorf("Expected nested override to set the aws_instance scope, saw %s", cfg.ScopeFor("aws_instance"))
+	}
+	if len(cfg.Categories) != 2 || cfg.Categories[1] != "two" {
+		t.Errorf("Expected override value to be parsed as yaml, saw %v", cfg.Categories)
+	}
+
+	if _, err = LoadConfig(path, []string{"scope"}, fakeEnv(nil)); err == nil {
+		t.Errorf("Expected an error for an override without a value")
+	}
+	if _, err = LoadConfig(path, []string{"name.first=aws"}, fakeEnv(nil)); err == nil {
+		t.Errorf("Expected an error for an override which traverses a scalar")
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	dir := writeLayerFixtures(t, map[string]string{
+		"dev.yaml":   devLayerFixture,
+		"base.yaml":  baseLayerFixture,
+		"a.yaml":     "extends: b.yaml\n",
+		"b.yaml":     "extends: a.yaml\n",
+		"typo.yaml":  "extends: base.yaml\ncategoreis: [aws]\n",
+		"blank.yaml": "extends: ''\n",
+		"empty.yaml": "categories: [aws]\n",
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := LoadConfig(filepath.Join(dir, "dev.yaml"), nil, fakeEnv(nil))
+	if err == nil {
+		t.Errorf("Expected an error when an environment variable without a default is unset")
+	}
+	for _, name := range []string{"a.yaml", "typo.yaml", "blank.yaml"} {
+		if _, err := LoadConfig(filepath.Join(dir, name), nil, fakeEnv(nil)); err == nil {
+			t.Errorf("Expected an error loading %s", name)
+		}
+	}
+
+	path := filepath.Join(dir, "empty.yaml")
+	_, err = LoadConfig(path, nil, fakeEnv(nil))
+	me, ok := err.(generator.MultiError)
+	if !ok {
+		t.Fatalf("Could not type assert LoadConfig error to MultiError, err=%v", err)
+	}
+	if len(me.Errors()) != 4 {
+		t.Errorf("Expected an error for each of the 4 missing required fields, saw %v", me.Errors())
+	}
+	if !strings.HasPrefix(me.Error(), path+": ") {
+		t.Errorf("Expected the error to name %s, saw: %s", path, me.Error())
+	}
+}
@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/template"
+)
+
+// ManifestFilename is the name of the manifest kept at the root of each output tree
+const ManifestFilename = ".terraform-provider-gen-manifest.json"
+
+// StagingDirPrefix starts the name of the directory inside the root of an output
+// tree in which a FileWriter stages its output until it is closed
+const StagingDirPrefix = ".terraform-provider-gen-staging-"
+
+// manifestVersion is bumped when the manifest format changes, older manifests are ignored
+const manifestVersion = 1
+
+// ManifestEntry records the hash of the inputs a file was generated from,
+// and the hash of the content that was written.
+type ManifestEntry struct {
+	Inputs  string `json:"inputs"`
+	Content string `json:"content"`
+}
+
+// Manifest maps the path of each generated file, relative to the manifest, to its hashes
+type Manifest struct {
+	Version int                      `json:"version"`
+	Files   map[string]ManifestEntry `json:"files"`
+}
+
+// HashInputs fingerprints the values a file is generated from. Each value
+// is hashed by its json encoding, except for byte slices and strings,
+// which are hashed as they are.
+func HashInputs(inputs ...interface{}) (string, error) {
+	h := sha256.New()
+	for _, in := range inputs {
+		var b []byte
+		switch v := in.(type) {
+		case []byte:
+			b = v
+		case string:
+			b = []byte(v)
+		default:
+			var err error
+			b, err = json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+		}
+		// length prefix each input so that adjacent inputs can't run together
+		fmt.Fprintf(h, "%d:", len(b))
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// templateSource returns a string representation of the parsed template at
+// path, for use as an input hash
+func templateSource(tg template.TemplateGetter, path string) (string, error) {
+	tpl, err := tg.Get(path)
+	if err != nil {
+		return "", err
+	}
+	if tpl.Tree == nil || tpl.Tree.Root == nil {
+		return "", nil
+	}
+	return tpl.Tree.Root.String(), nil
+}
+
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
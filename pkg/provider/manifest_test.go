@@ -0,0 +1,135 @@
+package provider
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func writeAndClose(t *testing.T, root string, files map[string]string, inputs string) []FileChange {
+	fw, err := NewFileWriter(afero.NewOsFs(), root, WriterOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error loading manifest: %s", err)
+	}
+	for name, content := range files {
+		if err := fw.WriteFile(filepath.Join(root, name), inputs, []byte(content)); err != nil {
+			t.Fatalf("Unexpected error writing %s: %s", name, err)
+		}
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("Unexpected error saving manifest: %s", err)
+	}
+	return fw.Changes()
+}
+
+func assertChange(t *testing.T, c FileChange, path string, status FileStatus, reason string) {
+	if c.Path != path || c.Status != status || c.Reason != reason {
+		t.Errorf("Expected %s to be %s (%s), saw %s %s (%s)", path, status, reason, c.Path, c.Status, c.Reason)
+	}
+}
+
+func TestFileWriterManifest(t *testing.T) {
+	root, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	changes := writeAndClose(t, root, map[string]string{"a/types.go": "package a", "b/types.go": "package b"}, "v1")
+	assertChange(t, changes[0], "a/types.go", FileCreated, "new file")
+	assertChange(t, changes[1], "b/types.go", FileCreated, "new file")
+
+	// same content, different inputs: nothing should be rewritten
+	changes = writeAndClose(t, root, map[string]string{"a/types.go": "package a", "b/types.go": "package b"}, "v2")
+	assertChange(t, changes[0], "a/types.go", FileUnchanged, "")
+	assertChange(t, changes[1], "b/types.go", FileUnchanged, "")
+
+	changes = writeAndClose(t, root, map[string]string{"a/types.go": "package a // v3"}, "v3")
+	assertChange(t, changes[0], "a/types.go", FileUpdated, "inputs changed")
+
+	err = ioutil.WriteFile(filepath.Join(root, "b", "types.go"), []byte("package b // edited"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes = writeAndClose(t, root, map[string]string{"b/types.go": "package b"}, "v2")
+	assertChange(t, changes[0], "b/types.go", FileUpdated, "modified outside the generator")
+
+	b, err := ioutil.ReadFile(filepath.Join(root, "b", "types.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "package b" {
+		t.Errorf("Expected b/types.go to be restored, saw %q", string(b))
+	}
+}
+
+func TestHashInputs(t *testing.T) {
+	a, err := HashInputs("ab", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := HashInputs("a", "bc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Errorf("Expected inputs to be hashed separately so that boundaries are significant")
+	}
+	c, err := HashInputs(Config{Name: "aws"}, []byte("ab"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := HashInputs(Config{Name: "aws"}, []byte("ab"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != d {
+		t.Errorf("Expected identical inputs to hash identically")
+	}
+}
+
+func TestFileWriterDryRun(t *testing.T) {
+	root, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	writeAndClose(t, root, map[string]string{"a/types.go": "package a\n\ntype A struct{}\n"}, "v1")
+
+	fw, err := NewFileWriter(afero.NewOsFs(), root, WriterOptions{DryRun: true, Diff: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"a/types.go": "package a\n\ntype B struct{}\n", "b/types.go": "package b\n"} {
+		if err := fw.WriteFile(filepath.Join(root, name), "v2", []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "b")); !os.IsNotExist(err) {
+		t.Errorf("Expected a dry run not to create directories")
+	}
+	b, err := ioutil.ReadFile(filepath.Join(root, "a", "types.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "package a\n\ntype A struct{}\n" {
+		t.Errorf("Expected a dry run not to modify existing files")
+	}
+
+	buf := new(bytes.Buffer)
+	fw.Report(buf)
+	aPath, bPath := filepath.Join(root, "a", "types.go"), filepath.Join(root, "b", "types.go")
+	expected := "--- " + aPath + "\n+++ " + aPath + "\n@@ -1,3 +1,3 @@\n package a\n \n-type A struct{}\n+type B struct{}\n" +
+		"--- /dev/null\n+++ " + bPath + "\n@@ -0,0 +1,1 @@\n+package b\n" +
+		"dry run: 2 of 2 files in " + root + " would be regenerated, 0 unchanged\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected dry run report, expected:\n%s\nsaw:\n%s", expected, buf.String())
+	}
+}
@@ -27,6 +27,7 @@ TypeName() string
 type TerraformResourceNamer interface {
 	PackageName() string
 	ManagedResourceName() string
+	ManagedResourceListName() string
 	APIVersion() string
 	APIGroup() string
 	KubernetesVersion() string
@@ -0,0 +1,245 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	// overlayExt is appended to the name of overlay files so they don't confuse the compiler
+	overlayExt = ".txt"
+	// patchExt is appended to the name of a unified diff applied to a generated file
+	patchExt = ".patch"
+	// declsExt is appended to the name of a Go file whose declarations are
+	// merged into a generated file, see mergeDecls
+	declsExt = ".decls.txt"
+)
+
+// Overlays replaces generated files with the files of a directory mirroring
+// the output tree under root, so the overlay for root/a/b.go is dir/a/b.go.txt.
+// Rather than replacing the whole file, dir/a/b.go.patch holds a unified diff
+// and dir/a/b.go.decls.txt holds Go declarations, which are applied to the
+// freshly generated file so it keeps up with regeneration. The overlays which
+// are applied are recorded, so that overlay files matching no generated file
+// can be reported. Overlays is safe for concurrent use, and a nil *Overlays,
+// or one without a directory, never overlays anything.
+type Overlays struct {
+	fs   afero.Fs
+	dir  string
+	root string
+
+	mu sync.Mutex
+	// applied maps the path of each overlay file applied to the path it replaced
+	applied map[string]string
+}
+
+func NewOverlays(fs afero.Fs, dir, root string) *Overlays {
+	return &Overlays{
+		fs:      fs,
+		dir:     dir,
+		root:    root,
+		applied: make(map[string]string),
+	}
+}
+
+// WithProviderOverlays replaces index_resources.go with its overlay in o,
+// which is usually shared with the Bootstrapper of the provider.
+func (st *SchemaTranslator) WithProviderOverlays(o *Overlays) *SchemaTranslator {
+	st.providerOverlays = o
+	return st
+}
+
+// WithOverlays replaces provider files with the overlays mirroring the
+// provider base path. Unmatched overlays are not reported by Bootstrap, since
+// a SchemaTranslator may share them, see WithProviderOverlays.
+func (bs *Bootstrapper) WithOverlays(o *Overlays) *Bootstrapper {
+	bs.overlays = o
+	return bs
+}
+
+// Lookup returns the content of the overlay for outputPath, and whether there
+// is one. Paths outside of root are never overlaid.
+func (o *Overlays) Lookup(outputPath string) ([]byte, bool, error) {
+	if o == nil || o.dir == "" {
+		return nil, false, nil
+	}
+	return o.read(outputPath, overlayExt)
+}
+
+// Patch applies the unified diff, then the declarations, overlaid onto the
+// generated content of outputPath. It returns the patched content along with
+// the source of each overlay applied, which should be hashed as inputs of the
+// file. An overlay which no longer applies is an error naming the file.
+func (o *Overlays) Patch(outputPath string, content []byte) ([]byte, []interface{}, error) {
+	if o == nil || o.dir == "" {
+		return content, nil, nil
+	}
+	applied := make([]interface{}, 0)
+	patch, ok, err := o.read(outputPath, patchExt)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ok {
+		content, err = applyUnifiedDiff(content, patch)
+		if err != nil {
+			return nil, nil, fmt.Errorf("patch %s does not apply to %s: %s", o.overlayPath(outputPath, patchExt), outputPath, err)
+		}
+		applied = append(applied, patch)
+	}
+	decls, ok, err := o.read(outputPath, declsExt)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ok {
+		content, err = mergeDecls(path.Base(outputPath), content, decls)
+		if err != nil {
+			return nil, nil, fmt.Errorf("declarations in %s cannot be merged into %s: %s", o.overlayPath(outputPath, declsExt), outputPath, err)
+		}
+		applied = append(applied, decls)
+	}
+	if len(applied) > 0 && isGoSource(outputPath) {
+		content, err = formatGoSource(outputPath, content)
+		if err != nil {
+			return nil, nil, fmt.Errorf("overlays left invalid Go in %s: %s", outputPath, err)
+		}
+	}
+	return content, applied, nil
+}
+
+// read returns the content of the overlay file with extension ext for
+// outputPath, and whether there is one, recording that it was applied.
+func (o *Overlays) read(outputPath, ext string) ([]byte, bool, error) {
+	overlayPath := o.overlayPath(outputPath, ext)
+	if overlayPath == "" {
+		return nil, false, nil
+	}
+	if _, err := o.fs.Stat(overlayPath); err != nil {
+		if os.IsNotExist(err) || errors.Is(err, syscall.ENOTDIR) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	content, err := afero.ReadFile(o.fs, overlayPath)
+	if err != nil {
+		return nil, false, err
+	}
+	o.mu.Lock()
+	o.applied[overlayPath] = outputPath
+	o.mu.Unlock()
+	return content, true, nil
+}
+
+// overlayPath returns the path of the overlay file with extension ext for
+// outputPath, or "" when outputPath is outside of root
+func (o *Overlays) overlayPath(outputPath, ext string) string {
+	rel, err := filepath.Rel(o.root, outputPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return ""
+	}
+	return path.Join(o.dir, rel+ext)
+}
+
+// Applied maps the path of each overlay file applied so far to the path of
+// the generated file it replaced.
+func (o *Overlays) Applied() map[string]string {
+	applied := make(map[string]string)
+	if o == nil {
+		return applied
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for k, v := range o.applied {
+		applied[k] = v
+	}
+	return applied
+}
+
+// Unmatched returns, in path order, every file in the overlay directory which
+// has not been applied to a generated file.
+func (o *Overlays) Unmatched() ([]string, error) {
+	unmatched := make([]string, 0)
+	if o == nil || o.dir == "" {
+		return unmatched, nil
+	}
+	applied := o.Applied()
+	err := afero.Walk(o.fs, o.dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if _, ok := applied[p]; !ok {
+			unmatched = append(unmatched, p)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	sort.Strings(unmatched)
+	return unmatched, nil
+}
+
+// WriteIfOverlaid writes the whole-file overlay for outputPath with fw in place
+// of the generated file, reporting it to out. It returns the content written,
+// and false when there is no overlay and the file should be generated. Only
+// the overlay is hashed, so the file is rewritten whenever the overlay changes.
+func (o *Overlays) WriteIfOverlaid(fw *FileWriter, out io.Writer, outputPath string) ([]byte, bool, error) {
+	content, ok, err := o.Lookup(outputPath)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	inputs, err := HashInputs(content)
+	if err != nil {
+		return nil, true, err
+	}
+	written, err := fw.Write(outputPath, inputs, content)
+	if err != nil {
+		return nil, true, err
+	}
+	fmt.Fprintf(out, "Overlayed %s\n", outputPath)
+	return written, true, nil
+}
+
+// Report prints the overlays which were applied. With warnUnmatched, it is
+// followed by a warning for each overlay file which matched no generated file,
+// which is usually a typo in its path or name. Only a run which generates every
+// file the overlays may replace should warn, since a partial run, eg of the types
+// alone, leaves the overlays of the other files unmatched.
+func (o *Overlays) Report(w io.Writer, warnUnmatched bool) error {
+	if o == nil || o.dir == "" {
+		return nil
+	}
+	applied := o.Applied()
+	paths := make([]string, 0, len(applied))
+	for p := range applied {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	fmt.Fprintf(w, "Applied %d overlays from %s\n", len(paths), o.dir)
+	for _, p := range paths {
+		fmt.Fprintf(w, "  %s -> %s\n", p, applied[p])
+	}
+	if !warnUnmatched {
+		return nil
+	}
+	unmatched, err := o.Unmatched()
+	if err != nil {
+		return err
+	}
+	for _, p := range unmatched {
+		fmt.Fprintf(w, "warning: overlay %s matched no generated file\n", p)
+	}
+	return nil
+}
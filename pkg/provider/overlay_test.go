@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestOverlays(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for p, content := range map[string]string{
+		"/overlays/thing/v1alpha1/types.go.txt":  "package v1alpha1 // overlay",
+		"/overlays/thing/v1alpha1/decode.go.txt": "package v1alpha1 // typo",
+		"/overlays/thing/v1alpha1/encode.go":     "package v1alpha1 // missing .txt",
+	} {
+		if err := afero.WriteFile(fs, p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	o := NewOverlays(fs, "/overlays", "/out")
+
+	content, ok, err := o.Lookup("/out/thing/v1alpha1/types.go")
+	if err != nil || !ok {
+		t.Fatalf("Expected an overlay for types.go, saw ok=%t err=%v", ok, err)
+	}
+	if string(content) != "package v1alpha1 // overlay" {
+		t.Errorf("Unexpected overlay content %q", string(content))
+	}
+	for _, p := range []string{"/out/thing/v1alpha1/doc.go", "/out/thing/v1alpha1/types.go/x", "/elsewhere/thing/v1alpha1/types.go"} {
+		if _, ok, err := o.Lookup(p); ok || err != nil {
+			t.Errorf("Expected no overlay for %s, saw ok=%t err=%v", p, ok, err)
+		}
+	}
+
+	expectedApplied := map[string]string{"/overlays/thing/v1alpha1/types.go.txt": "/out/thing/v1alpha1/types.go"}
+	if applied := o.Applied(); !reflect.DeepEqual(applied, expectedApplied) {
+		t.Errorf("Expected applied overlays %v, saw %v", expectedApplied, applied)
+	}
+	unmatched, err := o.Unmatched()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedUnmatched := []string{"/overlays/thing/v1alpha1/decode.go.txt", "/overlays/thing/v1alpha1/encode.go"}
+	if !reflect.DeepEqual(unmatched, expectedUnmatched) {
+		t.Errorf("Expected unmatched overlays %v, saw %v", expectedUnmatched, unmatched)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := o.Report(buf, false); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "warning") {
+		t.Errorf("Expected no warnings without warnUnmatched, saw:\n%s", buf.String())
+	}
+	buf.Reset()
+	if err := o.Report(buf, true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "warning: overlay /overlays/thing/v1alpha1/encode.go matched no generated file") {
+		t.Errorf("Expected the report to warn about encode.go, saw:\n%s", buf.String())
+	}
+}
+
+func TestOverlaysDisabled(t *testing.T) {
+	var o *Overlays
+	if _, ok, err := o.Lookup("/out/types.go"); ok || err != nil {
+		t.Errorf("Expected a nil Overlays not to overlay anything")
+	}
+	o = NewOverlays(afero.NewMemMapFs(), "", "/out")
+	if _, ok, err := o.Lookup("/out/types.go"); ok || err != nil {
+		t.Errorf("Expected Overlays without a directory not to overlay anything")
+	}
+	buf := new(bytes.Buffer)
+	if err := o.Report(buf, true); err != nil || buf.Len() != 0 {
+		t.Errorf("Expected no report without an overlay directory, saw %q (%v)", buf.String(), err)
+	}
+}
+
+func TestOverlaysPatchDoesNotApply(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	patch := "--- a/types.go\n+++ b/types.go\n@@ -1,1 +1,1 @@\n-package stale\n+package patched\n"
+	if err := afero.WriteFile(fs, "/overlays/thing/v1alpha1/types.go.patch", []byte(patch), 0644); err != nil {
+		t.Fatal(err)
+	}
+	o := NewOverlays(fs, "/overlays", "/out")
+	_, _, err := o.Patch("/out/thing/v1alpha1/types.go", []byte("package v1alpha1\n"))
+	if err == nil {
+		t.Fatalf("Expected an error from a patch which no longer applies")
+	}
+	expected := "patch /overlays/thing/v1alpha1/types.go.patch does not apply to /out/thing/v1alpha1/types.go: hunk 1 (@@ -1,1 +1,1 @@) does not apply"
+	if err.Error() != expected {
+		t.Errorf("Expected the error to name the overlay, the file and the hunk, saw: %s", err)
+	}
+}
@@ -6,150 +6,202 @@ import (
 	"io"
 	"os"
 	"path"
-	"syscall"
 
 	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/optimize"
 	"github.com/crossplane-contrib/terraform-provider-gen/pkg/template"
 	"github.com/crossplane-contrib/terraform-provider-gen/pkg/translate"
 	"github.com/hashicorp/terraform/providers"
+	"github.com/spf13/afero"
 )
 
 func (pt *PackageTranslator) WriteTypeDefFile(mr *generator.ManagedResource) error {
-	outputPath := pt.outputPath("types.go")
-	fmt.Printf("Writing typedefs for %s to %s\n", pt.namer.ManagedResourceName(), outputPath)
-	fh, err := os.OpenFile(outputPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
-	defer fh.Close()
-	if err != nil {
+	if overlaid, err := pt.overlaid("types.go"); err != nil || overlaid {
 		return err
 	}
+	outputPath := pt.outputPath("types.go")
+	fmt.Fprintf(pt.out, "Writing typedefs for %s to %s\n", pt.namer.ManagedResourceName(), outputPath)
 	renderer := generator.NewManagedResourceTypeDefRenderer(mr, pt.tg)
 	rendered, err := renderer.Render()
 	if err != nil {
 		return err
 	}
-	buf := bytes.NewBufferString(rendered)
-	_, err = io.Copy(fh, buf)
-	return err
+	return pt.writeFile(outputPath, "pkg/generator/types.go.tmpl", []byte(rendered))
 }
 
 func (pt *PackageTranslator) WriteEncoderFile(mr *generator.ManagedResource) error {
-	outputPath := pt.outputPath("encode.go")
-	fmt.Printf("Writing encoder for %s to %s\n", pt.namer.ManagedResourceName(), outputPath)
-	fh, err := os.OpenFile(outputPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
-	defer fh.Close()
-	if err != nil {
+	if overlaid, err := pt.overlaid("encode.go"); err != nil || overlaid {
 		return err
 	}
+	outputPath := pt.outputPath("encode.go")
+	fmt.Fprintf(pt.out, "Writing encoder for %s to %s\n", pt.namer.ManagedResourceName(), outputPath)
 	generated, err := translate.GenerateEncoders(mr, pt.tg)
 	if err != nil {
 		return err
 	}
-	buf := bytes.NewBufferString(generated)
-	_, err = io.Copy(fh, buf)
-	return err
+	return pt.writeFile(outputPath, "pkg/generator/encode.go.tmpl", []byte(generated))
 }
 
 func (pt *PackageTranslator) WriteDecodeFile(mr *generator.ManagedResource) error {
-	outputPath := pt.outputPath("decode.go")
-	fmt.Printf("Writing decoder for %s to %s\n", pt.namer.ManagedResourceName(), outputPath)
-	fh, err := os.OpenFile(outputPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
-	defer fh.Close()
-	if err != nil {
+	if overlaid, err := pt.overlaid("decode.go"); err != nil || overlaid {
 		return err
 	}
+	outputPath := pt.outputPath("decode.go")
+	fmt.Fprintf(pt.out, "Writing decoder for %s to %s\n", pt.namer.ManagedResourceName(), outputPath)
 	generated, err := translate.GenerateDecoders(mr, pt.tg)
 	if err != nil {
 		return err
 	}
-	buf := bytes.NewBufferString(generated)
-	_, err = io.Copy(fh, buf)
-	return err
+	return pt.writeFile(outputPath, "pkg/generator/decode.go.tmpl", []byte(generated))
 }
 
 func (pt *PackageTranslator) WriteCompareFile(mr *generator.ManagedResource) error {
-	outputPath := pt.outputPath("compare.go")
-	fmt.Printf("Writing merger for %s to %s\n", pt.namer.ManagedResourceName(), outputPath)
-	fh, err := os.OpenFile(outputPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
-	defer fh.Close()
-	if err != nil {
+	if overlaid, err := pt.overlaid("compare.go"); err != nil || overlaid {
 		return err
 	}
+	outputPath := pt.outputPath("compare.go")
+	fmt.Fprintf(pt.out, "Writing merger for %s to %s\n", pt.namer.ManagedResourceName(), outputPath)
 	generated, err := translate.GenerateMergers(mr, pt.tg)
 	if err != nil {
 		return err
 	}
-	buf := bytes.NewBufferString(generated)
-	_, err = io.Copy(fh, buf)
-	return err
+	return pt.writeFile(outputPath, "pkg/generator/compare.go.tmpl", []byte(generated))
+}
+
+// Optimize applies the field overrides configured for this resource,
+// followed by the standard optimizations, to a freshly translated ManagedResource.
+func (pt *PackageTranslator) Optimize(mr *generator.ManagedResource) (*generator.ManagedResource, error) {
+	chain := optimize.NewOptimizerChain(
+		optimize.NewFieldOverrider(pt.cfg.FieldOverridesFor(pt.namer.TerraformResourceName())),
+		optimize.Deduplicate,
+	)
+	return chain(mr)
+}
+
+// ApplyResourceConfig copies the categories, scope and printer columns configured
+// for this resource onto the ManagedResource before it is rendered.
+func (pt *PackageTranslator) ApplyResourceConfig(mr *generator.ManagedResource) error {
+	tfName := pt.namer.TerraformResourceName()
+	mr.CategoryTags = pt.cfg.CategoriesFor(tfName)
+	mr.Scope = pt.cfg.ScopeFor(tfName)
+	if err := mr.Validate(); err != nil {
+		return fmt.Errorf("%s: %s", tfName, err)
+	}
+	columns, err := PrinterColumns(mr, pt.cfg.ResourceConfig(tfName).PrinterColumns)
+	if err != nil {
+		return fmt.Errorf("%s: %s", tfName, err)
+	}
+	mr.PrinterColumns = columns
+	return nil
 }
 
-func (pt *PackageTranslator) WriteConfigureFile() error {
-	return pt.renderWithNamer("configure.go")
+func (pt *PackageTranslator) WriteConfigureFile(mr *generator.ManagedResource) error {
+	return pt.renderWithNamer("configure.go", mr)
 }
 
-func (pt *PackageTranslator) WriteDocFile() error {
-	return pt.renderWithNamer("doc.go")
+func (pt *PackageTranslator) WriteDocFile(mr *generator.ManagedResource) error {
+	return pt.renderWithNamer("doc.go", mr)
 }
 
-func (pt *PackageTranslator) WriteIndexFile() error {
-	return pt.renderWithNamer("index.go")
+func (pt *PackageTranslator) WriteIndexFile(mr *generator.ManagedResource) error {
+	return pt.renderWithNamer("index.go", mr)
 }
 
-func (pt *PackageTranslator) renderWithNamer(filename string) error {
-	overlaid, err := pt.overlaid(filename)
-	if err != nil {
+func (pt *PackageTranslator) renderWithNamer(filename string, mr *generator.ManagedResource) error {
+	if overlaid, err := pt.overlaid(filename); err != nil || overlaid {
 		return err
 	}
-	if overlaid {
-		return nil
-	}
 	outputPath := pt.outputPath(filename)
-	fmt.Printf("Writing %s for %s to %s\n", filename, pt.namer.ManagedResourceName(), outputPath)
-	fh, err := os.OpenFile(outputPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
-	defer fh.Close()
-	if err != nil {
-		return err
-	}
+	fmt.Fprintf(pt.out, "Writing %s for %s to %s\n", filename, pt.namer.ManagedResourceName(), outputPath)
 	ttpl, err := pt.tg.Get(pt.templatePath(filename))
 	if err != nil {
 		return err
 	}
 
 	buf := new(bytes.Buffer)
-	err = ttpl.Execute(buf, pt.namer)
+	err = ttpl.Execute(buf, pt.templateData(mr))
 	if err != nil {
 		return err
 	}
+	return pt.writeFile(outputPath, pt.templatePath(filename), buf.Bytes())
+}
 
-	_, err = io.Copy(fh, buf)
-	return err
+// writeFile writes content rendered by the template at tplPath, see writeGenerated
+func (pt *PackageTranslator) writeFile(outputPath, tplPath string, content []byte) error {
+	tplSource, err := templateSource(pt.tg, tplPath)
+	if err != nil {
+		return err
+	}
+	return pt.writeGenerated(outputPath, "template "+tplPath, content, tplSource)
 }
 
-func (pt *PackageTranslator) overlaid(filename string) (bool, error) {
-	if pt.overlayBasePath == "" {
-		return false, nil
+// writeGenerated formats Go content, applies any patch overlays, and hands it
+// to the FileWriter along with a hash of the inputs it was generated from:
+// the resource schema, the config for the resource, the source of the
+// generator, eg its template, the IR file the resource was loaded from and
+// the patch overlays. generatedBy names the generator in errors.
+func (pt *PackageTranslator) writeGenerated(outputPath, generatedBy string, content []byte, source interface{}) error {
+	if isGoSource(outputPath) {
+		formatted, err := formatGoSource(outputPath, content)
+		if err != nil {
+			return fmt.Errorf("%s generated invalid Go for %s in %s: %s", generatedBy, pt.namer.TerraformResourceName(), path.Base(outputPath), err)
+		}
+		content = formatted
 	}
-	overlayDir := pt.resourcePath(pt.overlayBasePath)
-	// we treat overlay files as .txt so they don't confuse the compiler
-	ftxt := fmt.Sprintf("%s.txt", filename)
-	overlayPath := path.Join(overlayDir, ftxt)
-	if _, err := os.Stat(overlayPath); os.IsNotExist(err) || err == syscall.ENOTDIR {
-		return false, nil
+	content, patches, err := pt.overlays.Patch(outputPath, content)
+	if err != nil {
+		return err
 	}
-	in, err := os.Open(overlayPath)
+	hashed := []interface{}{pt.resourceSchema, pt.cfg.resourceSlice(pt.namer.TerraformResourceName()), source}
+	if pt.ir != nil {
+		hashed = append(hashed, pt.ir)
+	}
+	inputs, err := HashInputs(append(hashed, patches...)...)
 	if err != nil {
-		return false, err
+		return err
 	}
-	defer in.Close()
-	outputPath := pt.outputPath(filename)
-	out, err := os.Create(outputPath)
+	written, err := pt.writer.Write(outputPath, inputs, content)
 	if err != nil {
-		return false, err
+		return err
+	}
+	pt.rendered[outputPath] = written
+	return nil
+}
+
+// resourceTemplateData is passed to the per-resource file templates. It embeds
+// the namer so that templates can keep calling the namer methods directly.
+type resourceTemplateData struct {
+	TerraformResourceNamer
+	Scope string
+	// Resource is the optimized ManagedResource, so templates can range over
+	// .Resource.Parameters.Fields and .Resource.Observation.Fields
+	Resource *generator.ManagedResource
+	// Config is the whole provider config
+	Config Config
+	// Schema is the terraform schema of the resource, with its Version and Block
+	Schema providers.Schema
+}
+
+func (pt *PackageTranslator) templateData(mr *generator.ManagedResource) resourceTemplateData {
+	return resourceTemplateData{
+		TerraformResourceNamer: pt.namer,
+		Scope:                  pt.cfg.ScopeFor(pt.namer.TerraformResourceName()),
+		Resource:               mr,
+		Config:                 pt.cfg,
+		Schema:                 pt.resourceSchema,
 	}
-	defer out.Close()
-	_, err = io.Copy(out, in)
-	fmt.Printf("Overlayed %s onto %s\n", overlayPath, outputPath)
-	return true, err
+}
+
+// overlaid writes the overlay for filename in place of the generated file,
+// returning false when there is no overlay and the file should be generated.
+func (pt *PackageTranslator) overlaid(filename string) (bool, error) {
+	outputPath := pt.outputPath(filename)
+	written, overlaid, err := pt.overlays.WriteIfOverlaid(pt.writer, pt.out, outputPath)
+	if err != nil || !overlaid {
+		return overlaid, err
+	}
+	pt.rendered[outputPath] = written
+	return true, nil
 }
 
 func (pt *PackageTranslator) templatePath(filename string) string {
@@ -169,8 +221,12 @@ func (pt *PackageTranslator) resourcePath(base string) string {
 }
 
 func (pt *PackageTranslator) EnsureOutputLocation() error {
-	fmt.Printf("creating basepath=%s\n", pt.basePath)
-	err := os.MkdirAll(pt.outputDir(), 0700)
+	// staged output directories are created when the staged files are moved into place
+	if pt.writer.DryRun() || pt.writer.Staged() {
+		return nil
+	}
+	fmt.Fprintf(pt.out, "creating basepath=%s\n", pt.basePath)
+	err := pt.writer.Fs().MkdirAll(pt.outputDir(), generatedDirMode)
 	if err != nil {
 		return err
 	}
@@ -184,6 +240,18 @@ type PackageTranslator struct {
 	tg              template.TemplateGetter
 	basePath        string
 	overlayBasePath string
+	overlays        *Overlays
+	// out receives progress messages, the SchemaTranslator buffers these
+	// per resource so that concurrent output is not interleaved
+	out    io.Writer
+	writer *FileWriter
+	// rendered holds the content of every file written for the resource, by path
+	rendered map[string][]byte
+	// resources are the names of every resource being generated, which are
+	// passed to plugins
+	resources []string
+	// ir is the content of the IR file the ManagedResource was loaded from, if any
+	ir []byte
 }
 
 type PackageImport struct {
@@ -191,6 +259,15 @@ type PackageImport struct {
 	Path string
 }
 
+// WithFs writes the files of the resource directly to fs, rather than the OS
+// filesystem. A SchemaTranslator replaces the writer with its own, see WithFs
+// on SchemaTranslator.
+func (pt *PackageTranslator) WithFs(fs afero.Fs) *PackageTranslator {
+	pt.writer = newFileWriter(fs, pt.basePath)
+	pt.overlays = NewOverlays(fs, pt.overlayBasePath, pt.basePath)
+	return pt
+}
+
 func (pt *PackageTranslator) PackageImport() PackageImport {
 	return PackageImport{
 		Name: pt.namer.PackageName(),
@@ -206,5 +283,9 @@ func NewPackageTranslator(s providers.Schema, namer TerraformResourceNamer, base
 		tg:              tg,
 		basePath:        basePath,
 		overlayBasePath: overlayBasePath,
+		overlays:        NewOverlays(afero.NewOsFs(), overlayBasePath, basePath),
+		out:             os.Stdout,
+		writer:          newFileWriter(afero.NewOsFs(), basePath),
+		rendered:        make(map[string][]byte),
 	}
 }
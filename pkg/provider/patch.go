@@ -0,0 +1,385 @@
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// hunk is a single hunk of a unified diff. Lines keep their leading ' ', '-'
+// or '+', so the lines of the original are those starting with ' ' or '-'.
+type hunk struct {
+	header             string
+	oldStart, oldLines int
+	newStart, newLines int
+	lines              []string
+}
+
+// before returns the lines the hunk expects to find
+func (h hunk) before() []string {
+	return h.side('+')
+}
+
+// after returns the lines the hunk leaves in their place
+func (h hunk) after() []string {
+	return h.side('-')
+}
+
+// side returns the lines of the hunk, without their prefix, skipping those
+// which start with skip
+func (h hunk) side(skip byte) []string {
+	lines := make([]string, 0, len(h.lines))
+	for _, l := range h.lines {
+		if l[0] != skip {
+			lines = append(lines, l[1:])
+		}
+	}
+	return lines
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parseUnifiedDiff returns the hunks of a unified diff of a single file. File
+// headers and any other lines outside of a hunk are ignored.
+func parseUnifiedDiff(patch []byte) ([]hunk, error) {
+	lines := strings.Split(string(patch), "\n")
+	hunks := make([]hunk, 0)
+	for i := 0; i < len(lines); i++ {
+		m := hunkHeader.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		h := hunk{header: m[0], oldLines: 1, newLines: 1}
+		h.oldStart, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			h.oldLines, _ = strconv.Atoi(m[2])
+		}
+		h.newStart, _ = strconv.Atoi(m[3])
+		if m[4] != "" {
+			h.newLines, _ = strconv.Atoi(m[4])
+		}
+		oldLeft, newLeft := h.oldLines, h.newLines
+		for oldLeft > 0 || newLeft > 0 {
+			i++
+			if i >= len(lines) {
+				return nil, fmt.Errorf("hunk %d (%s) is truncated", len(hunks)+1, h.header)
+			}
+			l := lines[i]
+			if strings.HasPrefix(l, `\`) {
+				// "\ No newline at end of file", generated Go always ends with one
+				continue
+			}
+			if l == "" {
+				// editors often strip the single space of an empty context line
+				l = " "
+			}
+			switch l[0] {
+			case ' ':
+				oldLeft--
+				newLeft--
+			case '-':
+				oldLeft--
+			case '+':
+				newLeft--
+			default:
+				return nil, fmt.Errorf("hunk %d (%s) has an unexpected line %q", len(hunks)+1, h.header, l)
+			}
+			if oldLeft < 0 || newLeft < 0 {
+				return nil, fmt.Errorf("hunk %d (%s) has more lines than its header", len(hunks)+1, h.header)
+			}
+			h.lines = append(h.lines, l)
+		}
+		hunks = append(hunks, h)
+	}
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("no hunks found")
+	}
+	return hunks, nil
+}
+
+// applyUnifiedDiff applies the hunks of patch to src in order. A hunk whose
+// lines have moved since the patch was made is applied where its original
+// lines are found closest to the line given in its header, so a patch keeps
+// applying as unrelated parts of the generated file change.
+func applyUnifiedDiff(src, patch []byte) ([]byte, error) {
+	hunks, err := parseUnifiedDiff(patch)
+	if err != nil {
+		return nil, err
+	}
+	text := string(src)
+	trailingNewline := strings.HasSuffix(text, "\n")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if text == "" {
+		lines = []string{}
+	}
+
+	// min is the first line a hunk may match, hunks apply in order and must not overlap
+	min, delta := 0, 0
+	for i, h := range hunks {
+		before, after := h.before(), h.after()
+		pos := -1
+		if len(before) == 0 {
+			// a pure insertion goes after line oldStart
+			pos = h.oldStart + delta
+			if pos < min || pos > len(lines) {
+				pos = -1
+			}
+		} else {
+			pos = findLines(lines, before, h.oldStart-1+delta, min)
+		}
+		if pos < 0 {
+			return nil, fmt.Errorf("hunk %d (%s) does not apply", i+1, h.header)
+		}
+		patched := make([]string, 0, len(lines)-len(before)+len(after))
+		patched = append(patched, lines[:pos]...)
+		patched = append(patched, after...)
+		patched = append(patched, lines[pos+len(before):]...)
+		lines = patched
+		min = pos + len(after)
+		delta += len(after) - len(before)
+	}
+	out := strings.Join(lines, "\n")
+	if trailingNewline {
+		out += "\n"
+	}
+	return []byte(out), nil
+}
+
+// findLines returns the index of want in lines nearest to expected and not
+// before min, or -1 when want is not found.
+func findLines(lines, want []string, expected, min int) int {
+	matches := func(at int) bool {
+		if at < min || at+len(want) > len(lines) {
+			return false
+		}
+		for i, l := range want {
+			if lines[at+i] != l {
+				return false
+			}
+		}
+		return true
+	}
+	for offset := 0; expected-offset >= min || expected+offset < len(lines); offset++ {
+		if matches(expected - offset) {
+			return expected - offset
+		}
+		if matches(expected + offset) {
+			return expected + offset
+		}
+	}
+	return -1
+}
+
+// mergeDecls applies the top level declarations of the Go source in overlay
+// to src. A function, method, type, var or const in overlay replaces the
+// declaration of the same name in src, or is appended when src has none.
+// Imports of overlay missing from src are added, and any which are no longer
+// used are removed when the result is formatted.
+func mergeDecls(filename string, src, overlay []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	srcFile, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	overlayFile, err := parser.ParseFile(fset, filename+".decls", overlay, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	offset := func(p token.Pos) int {
+		return fset.Position(p).Offset
+	}
+	existing := make(map[string]declRange)
+	for _, r := range declRanges(fset, srcFile, src) {
+		existing[r.key] = r
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	edits := make([]edit, 0)
+	replaced := make(map[int]bool)
+	appended := new(bytes.Buffer)
+	// names declared by one spec, eg var a, b = 1, 2, share a range
+	done := make(map[int]bool)
+	for _, r := range declRanges(fset, overlayFile, overlay) {
+		if done[r.start] {
+			continue
+		}
+		done[r.start] = true
+		target, ok := existing[r.key]
+		text := r.text
+		switch {
+		case ok && target.grouped:
+			text = r.doc + r.spec
+		case r.grouped:
+			// a spec of a grouped declaration needs a keyword of its own
+			text = r.doc + r.tok.String() + " " + r.spec
+		}
+		if ok {
+			if !replaced[target.start] {
+				replaced[target.start] = true
+				edits = append(edits, edit{start: target.start, end: target.end, text: text})
+			}
+			continue
+		}
+		fmt.Fprintf(appended, "\n%s\n", text)
+	}
+
+	if imports := missingImports(srcFile, overlayFile, overlay, offset); len(imports) > 0 {
+		if decl := importDecl(srcFile); decl != nil && decl.Lparen.IsValid() {
+			at := offset(decl.Rparen)
+			edits = append(edits, edit{start: at, end: at, text: strings.Join(imports, "\n") + "\n"})
+		} else {
+			at := offset(srcFile.Name.End())
+			edits = append(edits, edit{start: at, end: at, text: "\n\nimport (\n" + strings.Join(imports, "\n") + "\n)"})
+		}
+	}
+
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	merged := append([]byte(nil), src...)
+	for _, e := range edits {
+		merged = append(merged[:e.start], append([]byte(e.text), merged[e.end:]...)...)
+	}
+	merged = append(merged, appended.Bytes()...)
+	return formatGoSource(filename, merged)
+}
+
+// declRange is the extent in the source of a named top level declaration,
+// including its doc comment. For a spec of a grouped declaration, eg
+// type ( ... ), the extent is that of the spec alone.
+type declRange struct {
+	key        string
+	tok        token.Token
+	grouped    bool
+	start, end int
+	// text is the source of the extent, doc and spec split it into the doc
+	// comment and the declaration without its keyword
+	text, doc, spec string
+}
+
+// declRanges returns, in source order, the extent in src of every function,
+// method, type, var and const declared at the top level of f.
+func declRanges(fset *token.FileSet, f *ast.File, src []byte) []declRange {
+	offset := func(p token.Pos) int {
+		return fset.Position(p).Offset
+	}
+	docText := func(doc *ast.CommentGroup) string {
+		if doc == nil {
+			return ""
+		}
+		return string(src[offset(doc.Pos()):offset(doc.End())]) + "\n"
+	}
+	ranges := make([]declRange, 0)
+	for _, d := range f.Decls {
+		switch decl := d.(type) {
+		case *ast.FuncDecl:
+			key := "func " + decl.Name.Name
+			if decl.Recv != nil && len(decl.Recv.List) == 1 {
+				key = "func (" + receiverTypeName(decl.Recv.List[0].Type) + ") " + decl.Name.Name
+			}
+			r := declRange{key: key, tok: token.FUNC, start: offset(decl.Pos()), end: offset(decl.End())}
+			if decl.Doc != nil {
+				r.start = offset(decl.Doc.Pos())
+			}
+			r.text = string(src[r.start:r.end])
+			ranges = append(ranges, r)
+		case *ast.GenDecl:
+			if decl.Tok == token.IMPORT {
+				continue
+			}
+			grouped := decl.Lparen.IsValid()
+			for _, s := range decl.Specs {
+				r := declRange{tok: decl.Tok, grouped: grouped, spec: string(src[offset(s.Pos()):offset(s.End())])}
+				var doc *ast.CommentGroup
+				if grouped {
+					doc = specDoc(s)
+					r.start, r.end = offset(s.Pos()), offset(s.End())
+				} else {
+					doc = decl.Doc
+					r.start, r.end = offset(decl.Pos()), offset(decl.End())
+				}
+				if doc != nil {
+					r.start = offset(doc.Pos())
+				}
+				r.doc = docText(doc)
+				r.text = string(src[r.start:r.end])
+				for _, name := range specNames(s) {
+					r.key = decl.Tok.String() + " " + name
+					ranges = append(ranges, r)
+				}
+			}
+		}
+	}
+	return ranges
+}
+
+func specDoc(s ast.Spec) *ast.CommentGroup {
+	switch spec := s.(type) {
+	case *ast.TypeSpec:
+		return spec.Doc
+	case *ast.ValueSpec:
+		return spec.Doc
+	}
+	return nil
+}
+
+func specNames(s ast.Spec) []string {
+	switch spec := s.(type) {
+	case *ast.TypeSpec:
+		return []string{spec.Name.Name}
+	case *ast.ValueSpec:
+		names := make([]string, 0, len(spec.Names))
+		for _, n := range spec.Names {
+			names = append(names, n.Name)
+		}
+		return names
+	}
+	return nil
+}
+
+// receiverTypeName returns the name of the type of a method receiver,
+// without any pointer, so that T and *T methods of the same name collide
+func receiverTypeName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if id, ok := expr.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+func importDecl(f *ast.File) *ast.GenDecl {
+	for _, d := range f.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			return gd
+		}
+	}
+	return nil
+}
+
+// missingImports returns the source of each import spec in overlay whose
+// path is not already imported by src
+func missingImports(src, overlay *ast.File, overlaySrc []byte, offset func(token.Pos) int) []string {
+	imported := make(map[string]bool)
+	for _, is := range src.Imports {
+		imported[is.Path.Value] = true
+	}
+	missing := make([]string, 0)
+	for _, is := range overlay.Imports {
+		if imported[is.Path.Value] {
+			continue
+		}
+		missing = append(missing, string(overlaySrc[offset(is.Pos()):offset(is.End())]))
+	}
+	return missing
+}
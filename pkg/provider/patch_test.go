@@ -0,0 +1,143 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+const patchSource = `package v1alpha1
+
+import "fmt"
+
+func One() string {
+	return "one"
+}
+
+func Two() string {
+	return "two"
+}
+
+func Three() string {
+	return fmt.Sprint("three")
+}
+`
+
+func TestApplyUnifiedDiff(t *testing.T) {
+	// made against a version of the file with an extra line at the top, so both hunks have moved
+	patch := `--- a/thing.go
++++ b/thing.go
+@@ -7,3 +7,3 @@ func One() string {
+ 
+ func One() string {
+-	return "one"
++	return "uno"
+@@ -15,2 +15,3 @@
+ func Three() string {
++	// three
+ 	return fmt.Sprint("three")
+`
+	patched, err := applyUnifiedDiff([]byte(patchSource), []byte(patch))
+	if err != nil {
+		t.Fatalf("Unexpected error applying patch: %s", err)
+	}
+	expected := strings.Replace(patchSource, `"one"`, `"uno"`, 1)
+	expected = strings.Replace(expected, "func Three() string {\n", "func Three() string {\n\t// three\n", 1)
+	if string(patched) != expected {
+		t.Errorf("Unexpected result of applying patch:\n%s", string(patched))
+	}
+}
+
+func TestApplyUnifiedDiffConflict(t *testing.T) {
+	patch := `@@ -5,3 +5,3 @@
+ func One() string {
+ 	return "one"
+ }
+@@ -9,2 +9,2 @@
+ func Two() string {
+-	return "deux"
++	return "dos"
+`
+	_, err := applyUnifiedDiff([]byte(patchSource), []byte(patch))
+	if err == nil {
+		t.Fatalf("Expected an error applying a patch which no longer matches")
+	}
+	if err.Error() != "hunk 2 (@@ -9,2 +9,2 @@) does not apply" {
+		t.Errorf("Expected the error to name the hunk, saw %q", err)
+	}
+}
+
+func TestMergeDecls(t *testing.T) {
+	src := `package v1alpha1
+
+import "fmt"
+
+type (
+	// Thing is a thing
+	Thing struct{}
+
+	Other struct{}
+)
+
+// Name of the thing
+func (t *Thing) Name() string {
+	return fmt.Sprint("thing")
+}
+
+func New() *Thing {
+	return &Thing{}
+}
+`
+	overlay := `package v1alpha1
+
+import "strings"
+
+// Thing is a customized thing
+type Thing struct {
+	Name string
+}
+
+// Name of the thing, upper cased
+func (t Thing) Name() string {
+	return strings.ToUpper(t.Name)
+}
+
+func (t *Thing) Reset() {
+	t.Name = ""
+}
+`
+	expected := `package v1alpha1
+
+import (
+	"strings"
+)
+
+type (
+	// Thing is a customized thing
+	Thing struct {
+		Name string
+	}
+
+	Other struct{}
+)
+
+// Name of the thing, upper cased
+func (t Thing) Name() string {
+	return strings.ToUpper(t.Name)
+}
+
+func New() *Thing {
+	return &Thing{}
+}
+
+func (t *Thing) Reset() {
+	t.Name = ""
+}
+`
+	merged, err := mergeDecls("thing.go", []byte(src), []byte(overlay))
+	if err != nil {
+		t.Fatalf("Unexpected error merging declarations: %s", err)
+	}
+	if string(merged) != expected {
+		t.Errorf("Unexpected result of merging declarations:\n%s", string(merged))
+	}
+}
@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/genplugin"
+)
+
+func (c Config) pluginProvider(resources []string) genplugin.Provider {
+	return genplugin.Provider{
+		Name:                  c.Name,
+		PackagePath:           c.PackagePath,
+		BaseCRDVersion:        c.BaseCRDVersion,
+		ProviderConfigVersion: c.ProviderConfigVersion,
+		Resources:             resources,
+	}
+}
+
+// reservedBy returns what writes path, relative to a directory whose built-in
+// files are reserved and whose template files are templates, or "" when
+// nothing else writes it
+func reservedBy(p string, reserved []string, key string, templates []TemplateFile) string {
+	p = path.Clean(p)
+	for _, r := range reserved {
+		if p == r {
+			return "the generator"
+		}
+	}
+	for i, tf := range templates {
+		if p == path.Clean(tf.Output) {
+			return fmt.Sprintf("%s[%d]", key, i)
+		}
+	}
+	return ""
+}
+
+// checkPluginFile returns an error when a file returned by plugin p is
+// written by something else, either by is non-empty or an earlier plugin
+// returned it, and records the file in written.
+func checkPluginFile(p genplugin.Plugin, f genplugin.File, written map[string]string, by string) error {
+	name := path.Clean(f.Name)
+	if by == "" {
+		by = written[name]
+	}
+	if by != "" {
+		return fmt.Errorf("plugin %s returned %s, which is already written by %s", p.DisplayName(), f.Name, by)
+	}
+	written[name] = "plugin " + p.DisplayName()
+	return nil
+}
+
+// RunPlugins runs each plugin of the config for the resource, and writes the
+// files it returns to the resource package. A file with an overlay is
+// replaced by the overlay, as a built-in file would be.
+func (pt *PackageTranslator) RunPlugins(mr *generator.ManagedResource) error {
+	if len(pt.cfg.Plugins) == 0 {
+		return nil
+	}
+	req := genplugin.Request{
+		Provider: pt.cfg.pluginProvider(pt.resources),
+		Resource: &genplugin.Resource{
+			TerraformName:   pt.namer.TerraformResourceName(),
+			PackageName:     pt.namer.PackageName(),
+			APIVersion:      pt.namer.APIVersion(),
+			APIGroup:        pt.namer.APIGroup(),
+			Kind:            pt.namer.ManagedResourceName(),
+			ListKind:        pt.namer.ManagedResourceListName(),
+			ManagedResource: mr,
+			Schema:          pt.resourceSchema,
+		},
+	}
+	written := make(map[string]string)
+	for _, p := range pt.cfg.Plugins {
+		resp, err := genplugin.Run(p, req, pt.out)
+		if err != nil {
+			return err
+		}
+		for _, f := range resp.Files {
+			if err := checkPluginFile(p, f, written, reservedBy(f.Name, resourceFilenames, "resource-templates", pt.cfg.ResourceTemplates)); err != nil {
+				return err
+			}
+			if overlaid, err := pt.overlaid(f.Name); err != nil || overlaid {
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			outputPath := pt.outputPath(f.Name)
+			fmt.Fprintf(pt.out, "Writing %s from plugin %s to %s\n", f.Name, p.DisplayName(), outputPath)
+			if err := pt.writeGenerated(outputPath, "plugin "+p.DisplayName(), []byte(f.Content), []interface{}{p, f.Content}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RunPlugins runs each plugin of the config for the provider, and writes the
+// files it returns relative to the base path.
+func (bs *Bootstrapper) RunPlugins() error {
+	if len(bs.cfg.Plugins) == 0 {
+		return nil
+	}
+	req := genplugin.Request{
+		Provider: bs.cfg.pluginProvider(bs.templateData().Resources),
+	}
+	written := make(map[string]string)
+	for _, p := range bs.cfg.Plugins {
+		resp, err := genplugin.Run(p, req, bs.out)
+		if err != nil {
+			return err
+		}
+		for _, f := range resp.Files {
+			if err := checkPluginFile(p, f, written, reservedBy(f.Name, bs.cfg.providerFilenames(), "provider-templates", bs.cfg.ProviderTemplates)); err != nil {
+				return err
+			}
+			outPath := path.Join(bs.cfg.BasePath, f.Name)
+			_, overlaid, err := bs.overlays.WriteIfOverlaid(bs.writer, bs.out, outPath)
+			if err != nil {
+				return err
+			}
+			if overlaid {
+				continue
+			}
+			if err := bs.writeGenerated(outPath, "plugin "+p.DisplayName(), []byte(f.Content), []interface{}{p, f.Content}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
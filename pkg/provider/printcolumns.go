@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+)
+
+const atProviderJSONPath = ".status.atProvider"
+
+// PrinterColumns resolves the configured printer columns against the fields of
+// the resource's observation type. The result is the set of default crossplane
+// columns, with the configured columns inserted ahead of the trailing AGE column.
+func PrinterColumns(mr *generator.ManagedResource, cfgs []PrinterColumnConfig) ([]generator.PrinterColumn, error) {
+	defaults := generator.DefaultPrinterColumns()
+	last := len(defaults) - 1
+	columns := append([]generator.PrinterColumn{}, defaults[:last]...)
+	for _, pcc := range cfgs {
+		pc, err := resolvePrinterColumn(mr, pcc)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, pc)
+	}
+	return append(columns, defaults[last]), nil
+}
+
+func resolvePrinterColumn(mr *generator.ManagedResource, pcc PrinterColumnConfig) (generator.PrinterColumn, error) {
+	if pcc.Name == "" {
+		return generator.PrinterColumn{}, fmt.Errorf("printer column for field %q is missing a name", pcc.Field)
+	}
+	f, err := findObservationField(mr.Observation, pcc.Field)
+	if err != nil {
+		return generator.PrinterColumn{}, fmt.Errorf("printer column %s: %s", pcc.Name, err)
+	}
+	colType := pcc.Type
+	if colType == "" {
+		colType = printerColumnType(f)
+	}
+	return generator.PrinterColumn{
+		Name:     pcc.Name,
+		Type:     colType,
+		JSONPath: fmt.Sprintf("%s.%s", atProviderJSONPath, pcc.Field),
+		Priority: pcc.Priority,
+	}, nil
+}
+
+// findObservationField walks the dot separated path of json tag names
+// through the observation field tree. Slices can't be addressed by a
+// simple JSONPath so they are rejected.
+func findObservationField(obs generator.Field, fieldPath string) (generator.Field, error) {
+	current := obs
+	for _, name := range strings.Split(fieldPath, ".") {
+		found := false
+		for _, f := range current.Fields {
+			if f.Tag != nil && f.Tag.Json != nil && f.Tag.Json.Name == name {
+				current = f
+				found = true
+				break
+			}
+		}
+		if !found {
+			return generator.Field{}, fmt.Errorf("field %q not found in %s", fieldPath, obs.Name)
+		}
+		if current.IsSlice {
+			return generator.Field{}, fmt.Errorf("field %q traverses a list, which can't be displayed as a column", fieldPath)
+		}
+	}
+	if current.Type != generator.FieldTypeAttribute {
+		return generator.Field{}, fmt.Errorf("field %q is not an attribute", fieldPath)
+	}
+	return current, nil
+}
+
+// printerColumnType maps generator attribute types to the openapi types
+// accepted by the kubebuilder printcolumn marker
+func printerColumnType(f generator.Field) string {
+	switch f.AttributeField.Type {
+	case generator.AttributeTypeBool:
+		return "boolean"
+	case generator.AttributeTypeInt, generator.AttributeTypeInt8, generator.AttributeTypeInt16,
+		generator.AttributeTypeInt32, generator.AttributeTypeInt64, generator.AttributeTypeUint,
+		generator.AttributeTypeUint8, generator.AttributeTypeUint16, generator.AttributeTypeUint32,
+		generator.AttributeTypeUint64:
+		return "integer"
+	case generator.AttributeTypeFloat32, generator.AttributeTypeFloat64:
+		return "number"
+	}
+	return "string"
+}
@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+)
+
+func testObservationResource() *generator.ManagedResource {
+	mr := generator.NewManagedResource("Instance", "github.com/crossplane-contrib/fake")
+	mr.Observation = generator.Field{
+		Name: "InstanceObservation",
+		Type: generator.FieldTypeStruct,
+		Fields: []generator.Field{
+			{
+				Name:           "InstanceState",
+				Type:           generator.FieldTypeAttribute,
+				AttributeField: generator.AttributeField{Type: generator.AttributeTypeString},
+				Tag:            &generator.StructTag{Json: &generator.StructTagJson{Name: "instance_state"}},
+			},
+			{
+				Name: "RootBlockDevice",
+				Type: generator.FieldTypeStruct,
+				Tag:  &generator.StructTag{Json: &generator.StructTagJson{Name: "root_block_device"}},
+				Fields: []generator.Field{
+					{
+						Name:           "VolumeSize",
+						Type:           generator.FieldTypeAttribute,
+						AttributeField: generator.AttributeField{Type: generator.AttributeTypeInt64},
+						Tag:            &generator.StructTag{Json: &generator.StructTagJson{Name: "volume_size"}},
+					},
+				},
+			},
+		},
+	}
+	return mr
+}
+
+func TestPrinterColumns(t *testing.T) {
+	mr := testObservationResource()
+	cfgs := []PrinterColumnConfig{
+		{Name: "STATE", Field: "instance_state"},
+		{Name: "SIZE", Field: "root_block_device.volume_size", Priority: 1},
+	}
+	columns, err := PrinterColumns(mr, cfgs)
+	if err != nil {
+		t.Fatalf("Unexpected error from PrinterColumns: %s", err)
+	}
+	names := make([]string, 0)
+	for _, c := range columns {
+		names = append(names, c.Name)
+	}
+	expectedNames := []string{"READY", "SYNCED", "EXTERNAL-NAME", "STATE", "SIZE", "AGE"}
+	if len(names) != len(expectedNames) {
+		t.Fatalf("Unexpected columns from PrinterColumns. expected=%v, actual=%v", expectedNames, names)
+	}
+	for i := range names {
+		if names[i] != expectedNames[i] {
+			t.Errorf("Unexpected columns from PrinterColumns. expected=%v, actual=%v", expectedNames, names)
+			break
+		}
+	}
+	size := columns[4]
+	if size.JSONPath != ".status.atProvider.root_block_device.volume_size" {
+		t.Errorf("Unexpected JSONPath for nested column: %s", size.JSONPath)
+	}
+	if size.Type != "integer" {
+		t.Errorf("Expected column type to be inferred as integer, saw=%s", size.Type)
+	}
+	if size.Priority != 1 {
+		t.Errorf("Expected column priority=1, saw=%d", size.Priority)
+	}
+}
+
+func TestPrinterColumnsUnknownField(t *testing.T) {
+	mr := testObservationResource()
+	_, err := PrinterColumns(mr, []PrinterColumnConfig{{Name: "NOPE", Field: "not_a_field"}})
+	if err == nil {
+		t.Errorf("Expected an error from PrinterColumns for a field that does not exist")
+	}
+}
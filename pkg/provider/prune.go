@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/afero"
+)
+
+// OwnerMarkerFilename is written into every resource package directory the
+// generator creates. A directory holding a marker that does not belong to any
+// of the generated resources is stale, and its generated files can be removed
+// with --prune.
+const OwnerMarkerFilename = ".terraform-provider-gen-owned"
+
+// WriteOwnerMarker marks the output directory of the resource as owned by the generator
+func (pt *PackageTranslator) WriteOwnerMarker() error {
+	content := fmt.Sprintf("# This directory is generated by terraform-provider-gen for %s.\n"+
+		"# Its generated files are removed by generate --prune when the resource is no longer generated.\n", pt.namer.TerraformResourceName())
+	inputs, err := HashInputs(content)
+	if err != nil {
+		return err
+	}
+	return pt.writer.WriteFile(pt.outputPath(OwnerMarkerFilename), inputs, []byte(content))
+}
+
+// WithPrune controls whether stale resource packages are removed or only reported
+func (st *SchemaTranslator) WithPrune(prune bool) *SchemaTranslator {
+	st.prune = prune
+	return st
+}
+
+// pruneStale removes the generated files of, or with pruning disabled reports,
+// every owned resource package which does not belong to one of the named
+// resources. Files in a stale package which were not generated are kept and
+// reported, along with the package.
+func (st *SchemaTranslator) pruneStale(fw *FileWriter, names []string) error {
+	stale, err := st.staleResourceDirs(names)
+	if err != nil {
+		return err
+	}
+	for _, dir := range stale {
+		if !st.prune {
+			fmt.Fprintf(st.out, "Stale package %s is no longer generated, use --prune to remove it\n", dir)
+			continue
+		}
+		kept, err := fw.RemoveGenerated(dir, "resource is no longer generated")
+		if err != nil {
+			return err
+		}
+		if len(kept) > 0 {
+			fmt.Fprintf(st.out, "Stale package %s was kept, since it holds files which were not generated:\n", dir)
+			for _, f := range kept {
+				fmt.Fprintf(st.out, "  %s\n", f)
+			}
+		}
+	}
+	return nil
+}
+
+// staleResourceDirs walks basePath for directories holding an owner marker,
+// returning, in sorted order, those which are not the output directory of
+// one of the named resources.
+func (st *SchemaTranslator) staleResourceDirs(names []string) ([]string, error) {
+	expected := make(map[string]bool)
+	for _, name := range names {
+		namer := NewTerraformResourceNamer(st.cfg.Name, name, st.cfg.BaseCRDVersion)
+		expected[filepath.Clean(path.Join(st.basePath, namer.PackageName(), namer.APIVersion()))] = true
+	}
+	stale := make([]string, 0)
+	if _, err := st.fs.Stat(st.basePath); os.IsNotExist(err) {
+		return stale, nil
+	}
+	err := afero.Walk(st.fs, st.basePath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if isStagingDir(info) {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || info.Name() != OwnerMarkerFilename {
+			return nil
+		}
+		dir := filepath.Dir(p)
+		if !expected[filepath.Clean(dir)] {
+			stale = append(stale, dir)
+		}
+		return nil
+	})
+	sort.Strings(stale)
+	return stale, err
+}
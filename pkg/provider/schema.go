@@ -3,15 +3,20 @@ package provider
 import (
 	"bytes"
 	"fmt"
-	"github.com/crossplane-contrib/terraform-provider-gen/pkg/optimize"
 	"io"
 	"os"
 	"path"
+	"path/filepath"
+	"runtime"
 	"sort"
+	"sync"
 
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
 	"github.com/crossplane-contrib/terraform-provider-gen/pkg/template"
 	"github.com/crossplane-contrib/terraform-provider-gen/pkg/translate"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/verify"
 	"github.com/hashicorp/terraform/providers"
+	"github.com/spf13/afero"
 )
 
 type SchemaTranslatorConfiguration struct {
@@ -27,88 +32,374 @@ type SchemaTranslator struct {
 	tg              template.TemplateGetter
 	basePath        string
 	overlayBasePath string
+	jobs            int
+	out             io.Writer
+	writerOpts      WriterOptions
+	prune           bool
+	// checker type checks each resource package when set, see WithVerify
+	checker *verify.Checker
+	fs      afero.Fs
+	hooks   Hooks
+	// overlays replace generated resource files, and are set up by each run
+	overlays *Overlays
+	// providerOverlays replace index_resources.go, see WithProviderOverlays
+	providerOverlays *Overlays
+	// selected are the names of the resources generated by each run
+	selected []string
+	// irDir holds ManagedResources used in place of translating the schema, see WithIRDir
+	irDir string
+}
+
+// WithJobs sets the number of resources which are generated concurrently.
+// Values less than 1 use one worker per CPU. Output is identical regardless
+// of the number of jobs.
+func (st *SchemaTranslator) WithJobs(jobs int) *SchemaTranslator {
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+	st.jobs = jobs
+	return st
+}
+
+// WithWriterOptions controls how generated files are written, eg as a dry run
+func (st *SchemaTranslator) WithWriterOptions(opts WriterOptions) *SchemaTranslator {
+	st.writerOpts = opts
+	return st
 }
 
 func (st *SchemaTranslator) WriteGeneratedTypes() error {
-	for name, s := range st.schema.ResourceTypes {
-		if st.cfg.IsExcluded(name) {
-			fmt.Printf("Skipping resource %s", name)
-			continue
-		}
-		namer := NewTerraformResourceNamer(st.cfg.Name, name, st.cfg.BaseCRDVersion)
-		pt := NewPackageTranslator(s, namer, st.basePath, st.overlayBasePath, st.cfg, st.tg)
-		err := pt.EnsureOutputLocation()
-		if err != nil {
-			return err
-		}
-		mr := translate.SchemaToManagedResource(pt.namer.ManagedResourceName(), pt.cfg.PackagePath, pt.resourceSchema)
-		mr, err = optimize.Deduplicate(mr)
-		if err != nil {
-			return err
-		}
-		err = pt.WriteTypeDefFile(mr)
-		if err != nil {
-			return err
-		}
+	return st.run(st.writeTypes, typesRun)
+}
 
-		err = pt.WriteDocFile()
+func (st *SchemaTranslator) WriteGeneratedRuntime() error {
+	return st.run(st.writeRuntime, runtimeRun)
+}
+
+// WriteGeneratedAll writes both the types and the runtime methods for every
+// selected resource, translating each resource schema only once.
+func (st *SchemaTranslator) WriteGeneratedAll() error {
+	return st.run(st.writeAll, allRun)
+}
+
+func (st *SchemaTranslator) writeAll(pt *PackageTranslator, mr *generator.ManagedResource) error {
+	if err := st.writeTypes(pt, mr); err != nil {
+		return err
+	}
+	return st.writeRuntime(pt, mr)
+}
+
+// runKind is the set of files a run generates for each resource
+type runKind int
+
+const (
+	typesRun runKind = iota
+	runtimeRun
+	allRun
+)
+
+// run generates every selected resource with step, followed by the resource
+// implementation index unless only the types are generated. Overlays matching
+// no generated file are only reported when every file is generated. Stale
+// resource packages are pruned or reported once every resource has been
+// generated successfully. Output is staged and only moved into place when
+// every step succeeds, so a failed run leaves the output tree exactly as it was.
+func (st *SchemaTranslator) run(step resourceStep, kind runKind) error {
+	fw, err := NewFileWriter(st.fs, st.outputRoot(), st.writerOpts)
+	if err != nil {
+		return err
+	}
+	if err := st.stage(fw, step, kind); err != nil {
+		return abort(st.out, err, fw)
+	}
+	if err := fw.Close(); err != nil {
+		return err
+	}
+	fw.Report(st.out)
+	if err := st.overlays.Report(st.out, kind == allRun); err != nil {
+		return err
+	}
+	return st.hooks.written(fw.Changes())
+}
+
+// stage generates the output of run with fw, which is left for the caller to close
+func (st *SchemaTranslator) stage(fw *FileWriter, step resourceStep, kind runKind) error {
+	fw.rendered = st.hooks.Rendered
+	st.overlays = NewOverlays(st.fs, st.overlayBasePath, st.basePath)
+	names := st.selectedResourceNames()
+	st.selected = names
+	pis, err := st.generate(fw, names, step)
+	if err == nil && kind != typesRun {
+		err = st.writeResourceImplementationIndex(fw, pis)
+	}
+	if err == nil {
+		err = st.pruneStale(fw, names)
+	}
+	return err
+}
+
+// WriteGeneratedProvider bootstraps the provider with bs and then writes the
+// types and runtime methods of every selected resource with st. The output of
+// both is staged and moved into place together once every step succeeds, so a
+// failed run leaves both output trees exactly as they were. When bs and st
+// write to the same root they share a FileWriter, whose changes are passed to
+// the Written hook of bs.
+func WriteGeneratedProvider(bs *Bootstrapper, st *SchemaTranslator) error {
+	bfw, err := NewFileWriter(bs.fs, bs.cfg.BasePath, bs.opts)
+	if err != nil {
+		return err
+	}
+	fws := []*FileWriter{bfw}
+	sfw := bfw
+	if filepath.Clean(st.outputRoot()) != filepath.Clean(bs.cfg.BasePath) {
+		sfw, err = NewFileWriter(st.fs, st.outputRoot(), st.writerOpts)
 		if err != nil {
-			return err
+			return abort(bs.out, err, bfw)
 		}
+		fws = append(fws, sfw)
+	}
+	if err := bs.stage(bfw); err != nil {
+		return abort(bs.out, err, fws...)
+	}
+	if err := st.stage(sfw, st.writeAll, allRun); err != nil {
+		return abort(st.out, err, fws...)
+	}
+	if err := CloseAll(fws...); err != nil {
+		return err
+	}
+	bfw.Report(bs.out)
+	if err := bs.hooks.written(bfw.Changes()); err != nil {
+		return err
+	}
+	if sfw == bfw {
+		return st.overlays.Report(st.out, true)
+	}
+	sfw.Report(st.out)
+	if err := st.overlays.Report(st.out, true); err != nil {
+		return err
 	}
-	return nil
+	return st.hooks.written(sfw.Changes())
 }
 
-func (st *SchemaTranslator) WriteGeneratedRuntime() error {
-	pis := make([]PackageImport, 0)
-	for name, s := range st.schema.ResourceTypes {
-		if st.cfg.IsExcluded(name) {
-			fmt.Printf("Skipping resource %s", name)
-			continue
-		}
-		namer := NewTerraformResourceNamer(st.cfg.Name, name, st.cfg.BaseCRDVersion)
-		pt := NewPackageTranslator(s, namer, st.basePath, st.overlayBasePath, st.cfg, st.tg)
-		err := pt.EnsureOutputLocation()
-		if err != nil {
-			return err
+// abort discards the output staged by fws after err, reporting that nothing was
+// written. fws are aborted in reverse, so that a root created inside the root
+// of an earlier FileWriter is removed before the earlier one.
+func abort(out io.Writer, err error, fws ...*FileWriter) error {
+	for i := len(fws) - 1; i >= 0; i-- {
+		if aerr := fws[i].Abort(); aerr != nil {
+			return fmt.Errorf("%s, and failed to discard staged output: %s", err, aerr)
 		}
-		mr := translate.SchemaToManagedResource(pt.namer.ManagedResourceName(), pt.cfg.PackagePath, pt.resourceSchema)
-		mr, err = optimize.Deduplicate(mr)
-		if err != nil {
-			return err
+	}
+	for _, fw := range fws {
+		fmt.Fprintf(out, "generation failed, no files were written to %s\n", fw.root)
+	}
+	return err
+}
+
+// outputRoot is the directory holding the resource packages and index_resources.go
+func (st *SchemaTranslator) outputRoot() string {
+	return path.Dir(st.basePath)
+}
+
+// resourceStep is the work done for a single resource once it has been translated
+type resourceStep func(pt *PackageTranslator, mr *generator.ManagedResource) error
+
+// resourceResult holds the outcome of generating a single resource, including
+// the console output, which is buffered so it can be printed in a stable order.
+type resourceResult struct {
+	pi  PackageImport
+	out *bytes.Buffer
+	err error
+}
+
+// generate translates every selected resource and runs step against it, spreading
+// the resources over st.jobs workers. Console output for each resource is printed
+// in name order once the resource is complete, and failures are collected rather
+// than stopping the remaining resources. The returned PackageImports cover the
+// resources which were generated successfully.
+func (st *SchemaTranslator) generate(fw *FileWriter, names []string, step resourceStep) ([]PackageImport, error) {
+	results := make([]resourceResult, len(names))
+	done := make([]chan struct{}, len(names))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	work := make(chan int)
+	go func() {
+		for i := range names {
+			work <- i
 		}
-		err = pt.WriteEncoderFile(mr)
-		if err != nil {
-			return err
+		close(work)
+	}()
+	var wg sync.WaitGroup
+	for w := 0; w < st.jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				results[i] = st.generateResource(names[i], fw, step)
+				close(done[i])
+			}
+		}()
+	}
+
+	pis := make([]PackageImport, 0, len(names))
+	fail := generator.NewMultiError("failed to generate resources:")
+	for i, name := range names {
+		<-done[i]
+		_, _ = io.Copy(st.out, results[i].out)
+		if results[i].err != nil {
+			fail.Append(fmt.Errorf("%s: %s", name, results[i].err))
+			continue
 		}
-		err = pt.WriteDecodeFile(mr)
-		if err != nil {
-			return err
+		pis = append(pis, results[i].pi)
+	}
+	wg.Wait()
+	if len(fail.Errors()) > 0 {
+		return pis, fail
+	}
+	return pis, nil
+}
+
+func (st *SchemaTranslator) generateResource(name string, fw *FileWriter, step resourceStep) (result resourceResult) {
+	out := new(bytes.Buffer)
+	// the translate package panics on schema types it does not support; report
+	// those against the resource instead of taking down every other worker
+	defer func() {
+		if r := recover(); r != nil {
+			result = resourceResult{out: out, err: fmt.Errorf("panic: %v", r)}
 		}
-		err = pt.WriteCompareFile(mr)
-		if err != nil {
-			return err
+	}()
+	pt, mr, err := st.translateResource(name, out, fw)
+	if err != nil {
+		return resourceResult{out: out, err: err}
+	}
+	err = step(pt, mr)
+	if err == nil && st.checker != nil {
+		err = pt.Verify(st.checker)
+	}
+	return resourceResult{pi: pt.PackageImport(), out: out, err: err}
+}
+
+// selectedResourceNames returns the names of the resources in the schema
+// which are not excluded by the config, sorted for stable output.
+func (st *SchemaTranslator) selectedResourceNames() []string {
+	names := make([]string, 0, len(st.schema.ResourceTypes))
+	for name := range st.schema.ResourceTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	selected := make([]string, 0, len(names))
+	rs := st.cfg.Selector()
+	for _, name := range names {
+		if !rs.Select(name).Included {
+			fmt.Fprintf(st.out, "Skipping resource %s\n", name)
+			continue
 		}
+		selected = append(selected, name)
+	}
+	return selected
+}
 
-		err = pt.WriteConfigureFile()
-		if err != nil {
-			return err
+// translateResource prepares the output location for the named resource and
+// builds its optimized ManagedResource.
+func (st *SchemaTranslator) translateResource(name string, out io.Writer, fw *FileWriter) (*PackageTranslator, *generator.ManagedResource, error) {
+	pt := st.packageTranslator(name, out, fw)
+	err := pt.EnsureOutputLocation()
+	if err != nil {
+		return nil, nil, err
+	}
+	err = pt.WriteOwnerMarker()
+	if err != nil {
+		return nil, nil, err
+	}
+	mr, err := st.managedResource(pt, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pt, mr, nil
+}
+
+func (st *SchemaTranslator) packageTranslator(name string, out io.Writer, fw *FileWriter) *PackageTranslator {
+	namer := NewTerraformResourceNamer(st.cfg.Name, name, st.cfg.BaseCRDVersion)
+	pt := NewPackageTranslator(st.schema.ResourceTypes[name], namer, st.basePath, st.overlayBasePath, st.cfg, st.tg)
+	pt.out = out
+	pt.writer = fw
+	pt.overlays = st.overlays
+	pt.resources = st.selected
+	return pt
+}
+
+// managedResource loads the ManagedResource of the named resource from the IR
+// directory, or translates and optimizes it from the schema, then applies the
+// config of the resource. A loaded ManagedResource has already been translated
+// and optimized, so only the config and the Optimized hook are applied to it.
+func (st *SchemaTranslator) managedResource(pt *PackageTranslator, name string) (*generator.ManagedResource, error) {
+	mr, ir, err := st.loadIR(name)
+	if err != nil {
+		return nil, err
+	}
+	if mr != nil {
+		pt.ir = ir
+	} else {
+		mr = translate.SchemaToManagedResource(pt.namer.ManagedResourceName(), pt.cfg.PackagePath, pt.resourceSchema)
+		if err := st.hooks.translated(name, mr); err != nil {
+			return nil, err
 		}
-		err = pt.WriteIndexFile()
+		mr, err = pt.Optimize(mr)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		pis = append(pis, pt.PackageImport())
 	}
-	return st.writeResourceImplementationIndex(pis)
+	if err := pt.ApplyResourceConfig(mr); err != nil {
+		return nil, err
+	}
+	if err := st.hooks.optimized(name, mr); err != nil {
+		return nil, err
+	}
+	return mr, nil
 }
 
-func (st *SchemaTranslator) writeResourceImplementationIndex(pis []PackageImport) error {
-	dir := path.Dir(st.basePath)
-	err := os.MkdirAll(dir, 0700)
+func (st *SchemaTranslator) writeTypes(pt *PackageTranslator, mr *generator.ManagedResource) error {
+	err := pt.WriteTypeDefFile(mr)
+	if err != nil {
+		return err
+	}
+	err = pt.WriteDocFile(mr)
+	if err != nil {
+		return err
+	}
+	err = pt.WriteTemplateFiles(mr)
+	if err != nil {
+		return err
+	}
+	return pt.RunPlugins(mr)
+}
+
+func (st *SchemaTranslator) writeRuntime(pt *PackageTranslator, mr *generator.ManagedResource) error {
+	err := pt.WriteEncoderFile(mr)
+	if err != nil {
+		return err
+	}
+	err = pt.WriteDecodeFile(mr)
+	if err != nil {
+		return err
+	}
+	err = pt.WriteCompareFile(mr)
 	if err != nil {
 		return err
 	}
+	err = pt.WriteConfigureFile(mr)
+	if err != nil {
+		return err
+	}
+	return pt.WriteIndexFile(mr)
+}
+
+func (st *SchemaTranslator) writeResourceImplementationIndex(fw *FileWriter, pis []PackageImport) error {
+	outPath := path.Join(st.outputRoot(), "index_resources.go")
+	if _, overlaid, err := st.providerOverlays.WriteIfOverlaid(fw, st.out, outPath); err != nil || overlaid {
+		return err
+	}
 	tpl, err := st.tg.Get(RESOURCE_IMPLEMENTATIONS_PATH)
 	if err != nil {
 		return err
@@ -129,15 +420,24 @@ func (st *SchemaTranslator) writeResourceImplementationIndex(pis []PackageImport
 	if err != nil {
 		return err
 	}
+	content, err := formatGoSource(outPath, buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("template %s generated invalid Go in %s: %s", RESOURCE_IMPLEMENTATIONS_PATH, outPath, err)
+	}
+	content, patches, err := st.providerOverlays.Patch(outPath, content)
+	if err != nil {
+		return err
+	}
 
-	outPath := path.Join(dir, "index_resources.go")
-	fh, err := os.OpenFile(outPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
-	defer fh.Close()
+	tplSource, err := templateSource(st.tg, RESOURCE_IMPLEMENTATIONS_PATH)
 	if err != nil {
 		return err
 	}
-	_, err = io.Copy(fh, buf)
-	return err
+	inputs, err := HashInputs(append([]interface{}{st.cfg, pis, tplSource}, patches...)...)
+	if err != nil {
+		return err
+	}
+	return fw.WriteFile(outPath, inputs, content)
 }
 
 func NewSchemaTranslator(cfg Config, basePath, overlayBasePath string, schema providers.GetSchemaResponse, tg template.TemplateGetter) *SchemaTranslator {
@@ -147,5 +447,8 @@ func NewSchemaTranslator(cfg Config, basePath, overlayBasePath string, schema pr
 		cfg:             cfg,
 		schema:          schema,
 		tg:              tg,
+		jobs:            1,
+		out:             os.Stdout,
+		fs:              afero.NewOsFs(),
 	}
 }
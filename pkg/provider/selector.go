@@ -0,0 +1,200 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+)
+
+// ResourceRule selects terraform resources by name. Pattern can be an exact
+// resource name, a glob as understood by path.Match (eg aws_appmesh_*), or a
+// regular expression wrapped in slashes (eg /^aws_(alb|lb)_/).
+// Reason documents why the rule exists and is shown by list-resources.
+//
+// In yaml a rule can be written as a plain string, which is equivalent to a
+// rule with only a pattern, or as an object with pattern and reason keys.
+type ResourceRule struct {
+	Pattern string `json:"pattern"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// UnmarshalJSON accepts either a bare string or a {pattern, reason} object,
+// so that existing lists of resource names continue to work.
+func (r *ResourceRule) UnmarshalJSON(b []byte) error {
+	var pattern string
+	if err := json.Unmarshal(b, &pattern); err == nil {
+		r.Pattern = pattern
+		return nil
+	}
+	// the alias type prevents infinite recursion into this method
+	type rule ResourceRule
+	d := json.NewDecoder(strings.NewReader(string(b)))
+	d.DisallowUnknownFields()
+	return d.Decode((*rule)(r))
+}
+
+func (r ResourceRule) isRegexp() bool {
+	return len(r.Pattern) > 2 && strings.HasPrefix(r.Pattern, "/") && strings.HasSuffix(r.Pattern, "/")
+}
+
+func (r ResourceRule) isExact() bool {
+	return !r.isRegexp() && !strings.ContainsAny(r.Pattern, "*?[")
+}
+
+// compiledRule pairs a rule with a matcher built from its pattern
+type compiledRule struct {
+	ResourceRule
+	match func(string) bool
+}
+
+func compileRule(r ResourceRule) (compiledRule, error) {
+	if r.Pattern == "" {
+		return compiledRule{}, fmt.Errorf("pattern is required")
+	}
+	if r.isRegexp() {
+		re, err := regexp.Compile(r.Pattern[1 : len(r.Pattern)-1])
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("invalid regular expression %s: %s", r.Pattern, err)
+		}
+		return compiledRule{ResourceRule: r, match: re.MatchString}, nil
+	}
+	// path.Match only reports malformed patterns when it gets far enough
+	// to notice, so check the pattern against itself up front
+	if _, err := path.Match(r.Pattern, r.Pattern); err != nil {
+		return compiledRule{}, fmt.Errorf("invalid glob %s: %s", r.Pattern, err)
+	}
+	return compiledRule{ResourceRule: r, match: func(name string) bool {
+		matched, _ := path.Match(r.Pattern, name)
+		return matched
+	}}, nil
+}
+
+// ResourceSelection records whether a resource will be generated,
+// and which rule, if any, made that decision.
+type ResourceSelection struct {
+	Name     string
+	Included bool
+	// Rule is nil when no rule matched; this means the resource is included
+	// if there are no include rules, and excluded otherwise.
+	Rule *ResourceRule
+}
+
+// Reason describes why the resource was included or excluded
+func (rs ResourceSelection) Reason() string {
+	if rs.Rule == nil {
+		if rs.Included {
+			return "no rules matched"
+		}
+		return "not matched by include-resources"
+	}
+	if rs.Rule.Reason != "" {
+		return fmt.Sprintf("%s (%s)", rs.Rule.Reason, rs.Rule.Pattern)
+	}
+	return rs.Rule.Pattern
+}
+
+// ResourceSelector applies include and exclude rules with the following precedence:
+//  1. an exclude rule naming the resource exactly
+//  2. an include rule naming the resource exactly
+//  3. an exclude glob or regexp
+//  4. an include glob or regexp
+//
+// If no rule matches, the resource is included only when there are no include rules.
+type ResourceSelector struct {
+	include []compiledRule
+	exclude []compiledRule
+}
+
+// NewResourceSelector compiles the include and exclude rules. Every invalid
+// pattern is reported in the returned generator.MultiError.
+func NewResourceSelector(include, exclude []ResourceRule) (*ResourceSelector, error) {
+	fail := generator.NewMultiError("invalid resource rules:")
+	rs := &ResourceSelector{}
+	for i, r := range include {
+		cr, err := compileRule(r)
+		if err != nil {
+			fail.Append(fmt.Errorf("include-resources[%d]: %s", i, err))
+			continue
+		}
+		rs.include = append(rs.include, cr)
+	}
+	for i, r := range exclude {
+		cr, err := compileRule(r)
+		if err != nil {
+			fail.Append(fmt.Errorf("exclude-resources[%d]: %s", i, err))
+			continue
+		}
+		rs.exclude = append(rs.exclude, cr)
+	}
+	if len(fail.Errors()) > 0 {
+		return rs, fail
+	}
+	return rs, nil
+}
+
+// Select decides whether the named resource should be generated
+func (rs *ResourceSelector) Select(name string) ResourceSelection {
+	if r := firstMatch(rs.exclude, name, true); r != nil {
+		return ResourceSelection{Name: name, Included: false, Rule: r}
+	}
+	if r := firstMatch(rs.include, name, true); r != nil {
+		return ResourceSelection{Name: name, Included: true, Rule: r}
+	}
+	if r := firstMatch(rs.exclude, name, false); r != nil {
+		return ResourceSelection{Name: name, Included: false, Rule: r}
+	}
+	if r := firstMatch(rs.include, name, false); r != nil {
+		return ResourceSelection{Name: name, Included: true, Rule: r}
+	}
+	return ResourceSelection{Name: name, Included: len(rs.include) == 0}
+}
+
+// SelectAll applies Select to every name, returning the results sorted by name
+func (rs *ResourceSelector) SelectAll(names []string) []ResourceSelection {
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+	selections := make([]ResourceSelection, 0, len(sorted))
+	for _, name := range sorted {
+		selections = append(selections, rs.Select(name))
+	}
+	return selections
+}
+
+// Unmatched returns every rule that does not match any of the given names
+func (rs *ResourceSelector) Unmatched(names []string) (include []ResourceRule, exclude []ResourceRule) {
+	unmatched := func(rules []compiledRule) []ResourceRule {
+		result := make([]ResourceRule, 0)
+		for _, r := range rules {
+			found := false
+			for _, name := range names {
+				if r.match(name) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				result = append(result, r.ResourceRule)
+			}
+		}
+		return result
+	}
+	return unmatched(rs.include), unmatched(rs.exclude)
+}
+
+func firstMatch(rules []compiledRule, name string, exact bool) *ResourceRule {
+	for i := range rules {
+		r := rules[i]
+		if r.isExact() != exact {
+			continue
+		}
+		if r.match(name) {
+			return &rules[i].ResourceRule
+		}
+	}
+	return nil
+}
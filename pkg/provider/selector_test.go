@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+)
+
+func TestResourceSelectorPrecedence(t *testing.T) {
+	include := []ResourceRule{
+		{Pattern: "aws_s3_*"},
+		{Pattern: "aws_iam_role"},
+		{Pattern: "/^aws_(alb|lb)_/"},
+	}
+	exclude := []ResourceRule{
+		{Pattern: "aws_iam_*", Reason: "iam is managed elsewhere"},
+		{Pattern: "aws_s3_bucket", Reason: "duplicate nested fields"},
+		{Pattern: "/_listener_rule$/"},
+	}
+	rs, err := NewResourceSelector(include, exclude)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewResourceSelector: %s", err)
+	}
+	cases := map[string]bool{
+		// exact exclude beats glob include
+		"aws_s3_bucket": false,
+		// glob include
+		"aws_s3_bucket_policy": true,
+		// exact include beats glob exclude
+		"aws_iam_role": true,
+		// glob exclude
+		"aws_iam_user": false,
+		// regexp include
+		"aws_lb_listener": true,
+		// regexp exclude beats regexp include
+		"aws_lb_listener_rule": false,
+		// not matched by any include rule
+		"aws_instance": false,
+	}
+	for name, expected := range cases {
+		sel := rs.Select(name)
+		if sel.Included != expected {
+			t.Errorf("Unexpected selection for %s. expected included=%t, actual=%t (%s)", name, expected, sel.Included, sel.Reason())
+		}
+	}
+	if reason := rs.Select("aws_iam_user").Reason(); reason != "iam is managed elsewhere (aws_iam_*)" {
+		t.Errorf("Unexpected reason for aws_iam_user: %s", reason)
+	}
+}
+
+func TestResourceSelectorNoIncludes(t *testing.T) {
+	rs, err := NewResourceSelector(nil, []ResourceRule{{Pattern: "aws_appmesh_*"}})
+	if err != nil {
+		t.Fatalf("Unexpected error from NewResourceSelector: %s", err)
+	}
+	if !rs.Select("aws_instance").Included {
+		t.Errorf("Expected resources to be included when there are no include rules")
+	}
+	if rs.Select("aws_appmesh_route").Included {
+		t.Errorf("Expected aws_appmesh_route to be excluded by glob")
+	}
+}
+
+func TestResourceSelectorInvalidPatterns(t *testing.T) {
+	_, err := NewResourceSelector([]ResourceRule{{Pattern: "/(unclosed/"}}, []ResourceRule{{Pattern: "aws_[s3"}, {}})
+	if err == nil {
+		t.Fatalf("Expected errors for invalid patterns")
+	}
+	me := err.(generator.MultiError)
+	if len(me.Errors()) != 3 {
+		t.Errorf("Expected 3 errors from NewResourceSelector, saw %d: %s", len(me.Errors()), err)
+	}
+}
+
+func TestResourceRuleYAML(t *testing.T) {
+	cfg := `name: aws
+package-path: github.com/crossplane-contrib/provider-terraform-aws/generated/resources
+base-crd-version: v1alpha1
+provider-config-version: v1alpha1
+exclude-resources:
+ - aws_lambda_alias
+ - pattern: aws_appmesh_*
+   reason: duplicate nested fields
+`
+	c, err := ParseConfig([]byte(cfg))
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseConfig: %s", err)
+	}
+	if !c.IsExcluded("aws_lambda_alias") || !c.IsExcluded("aws_appmesh_route") {
+		t.Errorf("Expected plain and object exclude-resources entries to be honored")
+	}
+	if c.IsExcluded("aws_instance") {
+		t.Errorf("Did not expect aws_instance to be excluded")
+	}
+	if c.ExcludeResources[1].Reason != "duplicate nested fields" {
+		t.Errorf("Expected reason to be decoded, saw=%q", c.ExcludeResources[1].Reason)
+	}
+}
@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+)
+
+// TemplateFile renders an additional file from a template, which is found by
+// path in the template directories, eg examples/example.yaml.tmpl in a
+// --template-dir. Output is relative to the resource package for
+// resource-templates, and to base-path for provider-templates. The templates
+// are given the same data as the built-in templates, and their output is
+// formatted, overlaid and tracked in the manifest like any built-in file.
+type TemplateFile struct {
+	Template string `json:"template"`
+	Output   string `json:"output"`
+}
+
+// resourceFilenames are the files the generator writes into every resource package
+var resourceFilenames = []string{"types.go", "doc.go", "encode.go", "decode.go", "compare.go", "configure.go", "index.go", OwnerMarkerFilename}
+
+// providerFilenames returns the paths, relative to base-path, of the files
+// written when the provider is bootstrapped
+func (c Config) providerFilenames() []string {
+	return []string{
+		"cmd/provider/main.go",
+		"generated/index.go",
+		"generated/index_provider.go",
+		path.Join("generated/provider", c.ProviderConfigVersion, "doc.go"),
+		path.Join("generated/provider", c.ProviderConfigVersion, "types.go"),
+		path.Join("generated/provider", c.ProviderConfigVersion, "index.go"),
+		ManifestFilename,
+	}
+}
+
+// validateTemplateFiles reports template files with a missing template or
+// output, an output outside of its directory, or an output which is written
+// by another template file or by the generator itself.
+func validateTemplateFiles(key string, files []TemplateFile, reserved []string, fail generator.MultiError) {
+	taken := make(map[string]string)
+	for _, f := range reserved {
+		taken[f] = "the generator"
+	}
+	for i, tf := range files {
+		if tf.Template == "" {
+			fail.Append(fmt.Errorf("%s[%d]: template is required", key, i))
+		}
+		if tf.Output == "" {
+			fail.Append(fmt.Errorf("%s[%d]: output is required", key, i))
+			continue
+		}
+		out := path.Clean(tf.Output)
+		if path.IsAbs(out) || out == ".." || strings.HasPrefix(out, "../") {
+			fail.Append(fmt.Errorf("%s[%d]: output %s must be a relative path within its directory", key, i, tf.Output))
+			continue
+		}
+		if by, ok := taken[out]; ok {
+			fail.Append(fmt.Errorf("%s[%d]: output %s is already written by %s", key, i, tf.Output, by))
+			continue
+		}
+		taken[out] = fmt.Sprintf("%s[%d]", key, i)
+	}
+}
+
+// WriteTemplateFiles renders the resource-templates of the config for this
+// resource. An overlay at the output path of a template replaces it, as it
+// would a built-in file.
+func (pt *PackageTranslator) WriteTemplateFiles(mr *generator.ManagedResource) error {
+	for _, tf := range pt.cfg.ResourceTemplates {
+		if overlaid, err := pt.overlaid(tf.Output); err != nil || overlaid {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		outputPath := pt.outputPath(tf.Output)
+		fmt.Fprintf(pt.out, "Writing %s for %s to %s\n", tf.Template, pt.namer.ManagedResourceName(), outputPath)
+		tpl, err := pt.tg.Get(tf.Template)
+		if err != nil {
+			return err
+		}
+		buf := new(bytes.Buffer)
+		if err := tpl.Execute(buf, pt.templateData(mr)); err != nil {
+			return err
+		}
+		if err := pt.writeFile(outputPath, tf.Template, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTemplateFiles renders the provider-templates of the config
+func (bs *Bootstrapper) WriteTemplateFiles() error {
+	for _, tf := range bs.cfg.ProviderTemplates {
+		if err := bs.writeExecutedConfigTemplate(tf.Template, path.Join(bs.cfg.BasePath, tf.Output)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/pkg/errors"
+)
+
+var InvalidConfigNameEmpty error = errors.New("name is required")
+var InvalidConfigPackagePathEmpty error = errors.New("package-path is required")
+var InvalidConfigBaseCRDVersionEmpty error = errors.New("base-crd-version is required")
+var InvalidConfigProviderConfigVersionEmpty error = errors.New("provider-config-version is required")
+
+// Validate checks the semantics of a Config which can't be expressed by
+// strict decoding alone. All problems are reported at once via a generator.MultiError.
+func (c Config) Validate() error {
+	fail := generator.NewMultiError("Config.Validate() failed:")
+	if c.Name == "" {
+		fail.Append(InvalidConfigNameEmpty)
+	}
+	if c.PackagePath == "" {
+		fail.Append(InvalidConfigPackagePathEmpty)
+	}
+	if c.BaseCRDVersion == "" {
+		fail.Append(InvalidConfigBaseCRDVersionEmpty)
+	}
+	if c.ProviderConfigVersion == "" {
+		fail.Append(InvalidConfigProviderConfigVersionEmpty)
+	}
+	if err := validateScope(c.Scope); err != nil {
+		fail.Append(fmt.Errorf("scope: %s", err))
+	}
+
+	seen := make(map[string]bool)
+	for _, r := range c.ExcludeResources {
+		if seen[r.Pattern] {
+			fail.Append(fmt.Errorf("exclude-resources: %s is listed more than once", r.Pattern))
+		}
+		seen[r.Pattern] = true
+	}
+	if _, err := NewResourceSelector(c.IncludeResources, c.ExcludeResources); err != nil {
+		for _, e := range err.(generator.MultiError).Errors() {
+			fail.Append(e)
+		}
+	}
+
+	for _, name := range c.configuredResourceNames() {
+		rc := c.Resources[name]
+		if err := validateScope(rc.Scope); err != nil {
+			fail.Append(fmt.Errorf("resources.%s.scope: %s", name, err))
+		}
+		for i, pc := range rc.PrinterColumns {
+			if pc.Name == "" {
+				fail.Append(fmt.Errorf("resources.%s.printer-columns[%d]: name is required", name, i))
+			}
+			if pc.Field == "" {
+				fail.Append(fmt.Errorf("resources.%s.printer-columns[%d]: field is required", name, i))
+			}
+		}
+	}
+
+	for _, key := range c.fieldOverrideKeys() {
+		if !strings.Contains(key, ".") {
+			fail.Append(fmt.Errorf("field-overrides.%s: key must be a resource name followed by a field path, eg aws_instance.root_block_device.volume_size", key))
+			continue
+		}
+		if err := c.FieldOverrides[key].Validate(); err != nil {
+			fail.Append(fmt.Errorf("field-overrides.%s: %s", key, err))
+		}
+	}
+
+	validateTemplateFiles("resource-templates", c.ResourceTemplates, resourceFilenames, fail)
+	validateTemplateFiles("provider-templates", c.ProviderTemplates, c.providerFilenames(), fail)
+	for i, p := range c.Plugins {
+		if p.Command == "" {
+			fail.Append(fmt.Errorf("plugins[%d]: command is required", i))
+		}
+	}
+
+	if len(fail.Errors()) > 0 {
+		return fail
+	}
+	return nil
+}
+
+// ValidateAgainstSchema checks that every include/exclude rule matches at
+// least one resource, and that every resource named under resources exists
+// in the provider schema, so that typos don't go unnoticed.
+func (c Config) ValidateAgainstSchema(schema providers.GetSchemaResponse) error {
+	fail := generator.NewMultiError("Config.ValidateAgainstSchema() failed:")
+	names := make([]string, 0, len(schema.ResourceTypes))
+	for name := range schema.ResourceTypes {
+		names = append(names, name)
+	}
+	include, exclude := c.Selector().Unmatched(names)
+	for _, r := range include {
+		fail.Append(fmt.Errorf("include-resources: %s does not match any resource in the %s provider schema", r.Pattern, c.Name))
+	}
+	for _, r := range exclude {
+		fail.Append(fmt.Errorf("exclude-resources: %s does not match any resource in the %s provider schema", r.Pattern, c.Name))
+	}
+	for _, name := range c.configuredResourceNames() {
+		if _, ok := schema.ResourceTypes[name]; !ok {
+			fail.Append(fmt.Errorf("resources: %s is not a resource in the %s provider schema", name, c.Name))
+		}
+	}
+	for _, key := range c.fieldOverrideKeys() {
+		name := strings.SplitN(key, ".", 2)[0]
+		if _, ok := schema.ResourceTypes[name]; !ok {
+			fail.Append(fmt.Errorf("field-overrides: %s is not a resource in the %s provider schema", name, c.Name))
+		}
+	}
+	if len(fail.Errors()) > 0 {
+		return fail
+	}
+	return nil
+}
+
+// configuredResourceNames returns the keys of Config.Resources in sorted order
+// so that validation errors are reported in a stable order.
+func (c Config) configuredResourceNames() []string {
+	names := make([]string, 0, len(c.Resources))
+	for name := range c.Resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fieldOverrideKeys returns the keys of Config.FieldOverrides in sorted order
+func (c Config) fieldOverrideKeys() []string {
+	keys := make([]string, 0, len(c.FieldOverrides))
+	for key := range c.FieldOverrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func validateScope(scope string) error {
+	switch scope {
+	case "", generator.ResourceScopeCluster, generator.ResourceScopeNamespaced:
+		return nil
+	}
+	return fmt.Errorf("%q is not one of %s or %s", scope, generator.ResourceScopeCluster, generator.ResourceScopeNamespaced)
+}
@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/verify"
+	"github.com/spf13/afero"
+)
+
+// WithVerify type checks each generated resource package before anything is
+// written, failing the resource when its package does not compile.
+func (st *SchemaTranslator) WithVerify(enabled bool) *SchemaTranslator {
+	st.checker = nil
+	if enabled {
+		st.checker = verify.NewChecker()
+	}
+	return st
+}
+
+// Verify type checks the resource package as it will be after this run: the
+// Go files rendered for the resource, along with any other Go files already
+// in the output directory.
+func (pt *PackageTranslator) Verify(c *verify.Checker) error {
+	fmt.Fprintf(pt.out, "Verifying %s\n", pt.PackageImport().Path)
+	files := make(map[string][]byte)
+	for p, content := range pt.rendered {
+		if isGoSource(p) {
+			files[path.Base(p)] = content
+		}
+	}
+	fs := pt.writer.Fs()
+	infos, err := afero.ReadDir(fs, pt.outputDir())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if _, ok := files[name]; ok || info.IsDir() || !isGoSource(name) || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		content, err := afero.ReadFile(fs, path.Join(pt.outputDir(), name))
+		if err != nil {
+			return err
+		}
+		files[name] = content
+	}
+	return c.Check(pt.PackageImport().Path, files)
+}
@@ -0,0 +1,596 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	generatedFileMode os.FileMode = 0644
+	generatedDirMode  os.FileMode = 0755
+)
+
+type FileStatus string
+
+const (
+	FileCreated   FileStatus = "created"
+	FileUpdated   FileStatus = "updated"
+	FileUnchanged FileStatus = "unchanged"
+	FileDeleted   FileStatus = "deleted"
+)
+
+// FileChange describes what happened to a single file during generation
+type FileChange struct {
+	// Path is relative to the root of the FileWriter
+	Path   string
+	Status FileStatus
+	Reason string
+}
+
+// WriterOptions control how a FileWriter treats the output tree
+type WriterOptions struct {
+	// DryRun renders everything into memory without touching the output tree
+	DryRun bool
+	// Diff reports a unified diff for each file which would change,
+	// instead of a list of files. It only applies to a DryRun.
+	Diff bool
+}
+
+// FileWriter writes generated files under a root directory. Files whose
+// content is unchanged are not rewritten, so their mtimes are preserved,
+// and a manifest of input and content hashes is kept so that the reason
+// each file was regenerated can be reported. FileWriter is safe for
+// concurrent use.
+//
+// A FileWriter from NewFileWriter stages every write and removal in a
+// temporary directory inside the root. Nothing else in the root changes until
+// Close moves the staged files into place, and Abort discards them, so a
+// failed run never leaves a partially written tree behind.
+type FileWriter struct {
+	fs       afero.Fs
+	root     string
+	opts     WriterOptions
+	previous Manifest
+	current  Manifest
+	changes  []FileChange
+	// staging is the directory holding staged writes, or empty when files
+	// are written directly into the root
+	staging string
+	// created is the outermost directory NewFileWriter had to create for the
+	// root, which Abort removes again when it is left empty
+	created string
+	// staged maps the path of each staged file to its content hash
+	staged map[string]string
+	// removals are files, relative to root, which are removed by Close
+	removals []string
+	// pending holds the existing and new content of each file a dry run would change
+	pending map[string][2][]byte
+	removed map[string]bool
+	// rendered, when set, may replace the content of each file before it is written
+	rendered func(path string, content []byte) ([]byte, error)
+	mu       sync.Mutex
+}
+
+// NewFileWriter loads the manifest from root on fs, if there is one, and
+// prepares a staging directory inside root unless opts is a dry run, removing
+// any left behind by an earlier run. Close or Abort must be called to finish
+// with the FileWriter.
+func NewFileWriter(fs afero.Fs, root string, opts WriterOptions) (*FileWriter, error) {
+	fw := newFileWriter(fs, root)
+	fw.opts = opts
+	b, err := afero.ReadFile(fs, fw.manifestPath())
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		m := Manifest{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, fmt.Errorf("%s: %s", fw.manifestPath(), err)
+		}
+		if m.Version == manifestVersion && m.Files != nil {
+			fw.previous = m
+		}
+	}
+	if opts.DryRun {
+		return fw, nil
+	}
+	root = filepath.Clean(root)
+	if err := removeStagingDirs(fs, root); err != nil {
+		return nil, err
+	}
+	fw.created, err = firstMissingDir(fs, root)
+	if err != nil {
+		return nil, err
+	}
+	// stage inside the root so that files can be renamed into place, even when
+	// only the root itself is writable
+	if err := fs.MkdirAll(root, generatedDirMode); err != nil {
+		return nil, err
+	}
+	fw.staging, err = afero.TempDir(fs, root, StagingDirPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return fw, nil
+}
+
+// removeStagingDirs removes staging directories left in root by a run which
+// did not get to Close or Abort
+func removeStagingDirs(fs afero.Fs, root string) error {
+	infos, err := afero.ReadDir(fs, root)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		if isStagingDir(info) {
+			if err := fs.RemoveAll(filepath.Join(root, info.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isStagingDir is true for the staging directory of a FileWriter, which walks
+// of the output tree skip
+func isStagingDir(info os.FileInfo) bool {
+	return info.IsDir() && strings.HasPrefix(info.Name(), StagingDirPrefix)
+}
+
+// firstMissingDir returns the outermost directory of dir, or dir itself,
+// which does not exist yet, or an empty string when dir exists
+func firstMissingDir(fs afero.Fs, dir string) (string, error) {
+	missing := ""
+	for d := dir; ; d = filepath.Dir(d) {
+		_, err := fs.Stat(d)
+		if err == nil {
+			return missing, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		missing = d
+		if filepath.Dir(d) == d {
+			return missing, nil
+		}
+	}
+}
+
+// newFileWriter returns a FileWriter with an empty manifest which writes
+// each file directly into root on fs, replacing it atomically.
+func newFileWriter(fs afero.Fs, root string) *FileWriter {
+	return &FileWriter{
+		fs:       fs,
+		root:     root,
+		previous: Manifest{Version: manifestVersion, Files: make(map[string]ManifestEntry)},
+		current:  Manifest{Version: manifestVersion, Files: make(map[string]ManifestEntry)},
+		staged:   make(map[string]string),
+		pending:  make(map[string][2][]byte),
+		removed:  make(map[string]bool),
+	}
+}
+
+// DryRun is true when the FileWriter only records what it would write
+func (fw *FileWriter) DryRun() bool {
+	return fw.opts.DryRun
+}
+
+// Staged is true when writes are held back until Close
+func (fw *FileWriter) Staged() bool {
+	return fw.staging != ""
+}
+
+// Fs is the filesystem the FileWriter writes to
+func (fw *FileWriter) Fs() afero.Fs {
+	return fw.fs
+}
+
+func (fw *FileWriter) manifestPath() string {
+	return filepath.Join(fw.root, ManifestFilename)
+}
+
+func (fw *FileWriter) stagedPath(rel string) string {
+	return filepath.Join(fw.staging, "new", rel)
+}
+
+// WriteFile writes content to outPath unless the file already has exactly that
+// content. inputs is a hash of everything the content was generated from, see HashInputs.
+func (fw *FileWriter) WriteFile(outPath, inputs string, content []byte) error {
+	_, err := fw.Write(outPath, inputs, content)
+	return err
+}
+
+// Write is WriteFile, returning the content as written, after the Rendered
+// hook has run, so that it can be verified.
+func (fw *FileWriter) Write(outPath, inputs string, content []byte) ([]byte, error) {
+	rel, err := filepath.Rel(fw.root, outPath)
+	if err != nil {
+		return nil, err
+	}
+	if fw.rendered != nil {
+		content, err = fw.rendered(outPath, content)
+		if err != nil {
+			return nil, err
+		}
+	}
+	contentHash := hashBytes(content)
+	change := FileChange{Path: rel}
+
+	existing, err := afero.ReadFile(fw.fs, outPath)
+	switch {
+	case os.IsNotExist(err):
+		change.Status = FileCreated
+		change.Reason = "new file"
+	case err != nil:
+		return nil, err
+	case hashBytes(existing) == contentHash:
+		change.Status = FileUnchanged
+	default:
+		change.Status = FileUpdated
+		change.Reason = fw.updateReason(rel, inputs, hashBytes(existing))
+	}
+
+	if change.Status != FileUnchanged && !fw.opts.DryRun {
+		target := outPath
+		if fw.staging != "" {
+			target = fw.stagedPath(rel)
+		}
+		if err := writeFileAtomic(fw.fs, target, content, generatedFileMode); err != nil {
+			return nil, err
+		}
+	}
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	fw.current.Files[rel] = ManifestEntry{Inputs: inputs, Content: contentHash}
+	fw.changes = append(fw.changes, change)
+	if change.Status != FileUnchanged {
+		if fw.staging != "" {
+			fw.staged[rel] = contentHash
+		}
+		if fw.opts.DryRun {
+			fw.pending[rel] = [2][]byte{existing, content}
+		}
+	}
+	return content, nil
+}
+
+func (fw *FileWriter) updateReason(rel, inputs, existingHash string) string {
+	prev, ok := fw.previous.Files[rel]
+	switch {
+	case !ok:
+		return "not in manifest"
+	case prev.Content != existingHash:
+		return "modified outside the generator"
+	case prev.Inputs != inputs:
+		return "inputs changed"
+	}
+	return "generator output changed"
+}
+
+// RemoveGenerated deletes the files in dir which the manifest records as
+// generated, along with the owner marker, recording each as deleted for the
+// given reason. Any other file, eg one written by hand, is left in place and
+// returned, so dir is only removed once nothing else is left in it. Parent
+// directories below the root which are left empty are removed as well.
+func (fw *FileWriter) RemoveGenerated(dir, reason string) ([]string, error) {
+	files := make([]string, 0)
+	kept := make([]string, 0)
+	err := afero.Walk(fw.fs, dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if isStagingDir(info) {
+			return filepath.SkipDir
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(fw.root, p)
+		if err != nil {
+			return err
+		}
+		if _, ok := fw.previous.Files[rel]; ok || info.Name() == OwnerMarkerFilename {
+			files = append(files, p)
+		} else {
+			kept = append(kept, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		rel, err := filepath.Rel(fw.root, f)
+		if err != nil {
+			return nil, err
+		}
+		existing, err := afero.ReadFile(fw.fs, f)
+		if err != nil {
+			return nil, err
+		}
+		fw.mu.Lock()
+		fw.changes = append(fw.changes, FileChange{Path: rel, Status: FileDeleted, Reason: reason})
+		fw.removed[rel] = true
+		if fw.opts.DryRun {
+			fw.pending[rel] = [2][]byte{existing, nil}
+		}
+		if fw.staging != "" {
+			fw.removals = append(fw.removals, rel)
+		}
+		fw.mu.Unlock()
+	}
+	if fw.opts.DryRun || fw.staging != "" {
+		return kept, nil
+	}
+	for _, f := range files {
+		if err := fw.fs.Remove(f); err != nil {
+			return nil, err
+		}
+		fw.removeEmptyParents(f)
+	}
+	return kept, nil
+}
+
+// removeEmptyParents removes the parents of p, up to but excluding the root,
+// for as long as they are empty
+func (fw *FileWriter) removeEmptyParents(p string) {
+	root := filepath.Clean(fw.root)
+	for parent := filepath.Dir(filepath.Clean(p)); parent != root && strings.HasPrefix(parent, root); parent = filepath.Dir(parent) {
+		if !fw.isEmptyDir(parent) || fw.fs.Remove(parent) != nil {
+			break
+		}
+	}
+}
+
+// Changes returns every file written so far, sorted by path
+func (fw *FileWriter) Changes() []FileChange {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	changes := append([]FileChange{}, fw.changes...)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// Report prints the files which were created, updated or deleted, and a count of
+// those left as they were. For a dry run with WriterOptions.Diff, a unified diff of
+// each file which would change is printed instead of the list of files.
+func (fw *FileWriter) Report(w io.Writer) {
+	changes := fw.Changes()
+	unchanged, deleted := 0, 0
+	for _, c := range changes {
+		switch c.Status {
+		case FileUnchanged:
+			unchanged++
+			continue
+		case FileDeleted:
+			deleted++
+		}
+		outPath := filepath.Join(fw.root, c.Path)
+		if fw.opts.DryRun && fw.opts.Diff {
+			fromPath, toPath := outPath, outPath
+			switch c.Status {
+			case FileCreated:
+				fromPath = "/dev/null"
+			case FileDeleted:
+				toPath = "/dev/null"
+			}
+			contents := fw.pending[c.Path]
+			fmt.Fprint(w, unifiedDiff(fromPath, toPath, contents[0], contents[1]))
+			continue
+		}
+		fmt.Fprintf(w, "%s %s (%s)\n", c.Status, outPath, c.Reason)
+	}
+	generated := len(changes) - deleted
+	summary := fmt.Sprintf("%d of %d files in %s", generated-unchanged, generated, fw.root)
+	if fw.opts.DryRun {
+		summary = fmt.Sprintf("dry run: %s would be regenerated, %d unchanged", summary, unchanged)
+	} else {
+		summary = fmt.Sprintf("regenerated %s, %d unchanged", summary, unchanged)
+	}
+	if deleted > 0 {
+		summary = fmt.Sprintf("%s, %d removed", summary, deleted)
+	}
+	fmt.Fprintln(w, summary)
+}
+
+// Close saves the manifest and, for a staged FileWriter, moves the staged files
+// into place. Manifest entries for files that were not written during this run
+// are carried over, so that commands generating different subsets of the tree
+// can share a manifest. Nothing is saved for a dry run.
+func (fw *FileWriter) Close() error {
+	return CloseAll(fw)
+}
+
+// CloseAll closes each of fws, which must have different roots, as a single
+// step: every staged file is validated before any is moved into place, and if
+// any file cannot be moved, every file already moved by any of fws is restored.
+func CloseAll(fws ...*FileWriter) error {
+	staged := make([]*FileWriter, 0, len(fws))
+	for _, fw := range fws {
+		if fw.opts.DryRun {
+			continue
+		}
+		fw.mu.Lock()
+		defer fw.mu.Unlock()
+		if fw.staging != "" {
+			defer fw.fs.RemoveAll(fw.staging)
+		}
+		b, err := fw.manifest()
+		if err != nil {
+			return err
+		}
+		if fw.staging == "" {
+			if err := writeFileAtomic(fw.fs, fw.manifestPath(), b, generatedFileMode); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeFileAtomic(fw.fs, fw.stagedPath(ManifestFilename), b, generatedFileMode); err != nil {
+			return err
+		}
+		fw.staged[ManifestFilename] = hashBytes(b)
+		staged = append(staged, fw)
+	}
+	for _, fw := range staged {
+		if err := fw.validateStaged(); err != nil {
+			return err
+		}
+	}
+	return commit(staged)
+}
+
+// manifest returns the saved form of the manifest, merging the entries of
+// this run into those of the previous one
+func (fw *FileWriter) manifest() ([]byte, error) {
+	m := Manifest{Version: manifestVersion, Files: make(map[string]ManifestEntry)}
+	for p, e := range fw.previous.Files {
+		if fw.removed[p] {
+			continue
+		}
+		m.Files[p] = e
+	}
+	for p, e := range fw.current.Files {
+		m.Files[p] = e
+	}
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// Abort discards everything staged, leaving the root untouched. A root which
+// NewFileWriter had to create is removed again.
+func (fw *FileWriter) Abort() error {
+	if fw.staging == "" {
+		return nil
+	}
+	if err := fw.fs.RemoveAll(fw.staging); err != nil {
+		return err
+	}
+	if fw.created == "" {
+		return nil
+	}
+	for dir := filepath.Clean(fw.root); strings.HasPrefix(dir, fw.created); dir = filepath.Dir(dir) {
+		if !fw.isEmptyDir(dir) || fw.fs.Remove(dir) != nil || dir == fw.created {
+			break
+		}
+	}
+	return nil
+}
+
+// commit moves the validated files staged by each of fws into their roots.
+// Files being replaced or removed are first moved aside into the staging
+// directory, so that if any step fails every completed step can be undone.
+// Files are moved one at a time, since not every afero.Fs can rename a
+// directory with contents.
+func commit(fws []*FileWriter) error {
+	type move struct {
+		fs       afero.Fs
+		from, to string
+	}
+	done := make([]move, 0)
+	rename := func(fs afero.Fs, from, to string) error {
+		if err := fs.MkdirAll(filepath.Dir(to), generatedDirMode); err != nil {
+			return err
+		}
+		if err := fs.Rename(from, to); err != nil {
+			return err
+		}
+		done = append(done, move{fs, from, to})
+		return nil
+	}
+	rollback := func(err error) error {
+		for i := len(done) - 1; i >= 0; i-- {
+			if rerr := done[i].fs.Rename(done[i].to, done[i].from); rerr != nil {
+				return fmt.Errorf("%s, and failed to restore %s: %s", err, done[i].from, rerr)
+			}
+		}
+		return err
+	}
+
+	for _, fw := range fws {
+		paths := make([]string, 0, len(fw.staged))
+		for rel := range fw.staged {
+			paths = append(paths, rel)
+		}
+		sort.Strings(paths)
+		for _, rel := range paths {
+			target := filepath.Join(fw.root, rel)
+			if _, err := fw.fs.Stat(target); err == nil {
+				if err := rename(fw.fs, target, filepath.Join(fw.staging, "old", rel)); err != nil {
+					return rollback(err)
+				}
+			}
+			if err := rename(fw.fs, fw.stagedPath(rel), target); err != nil {
+				return rollback(err)
+			}
+		}
+		for _, rel := range fw.removals {
+			if err := rename(fw.fs, filepath.Join(fw.root, rel), filepath.Join(fw.staging, "old", rel)); err != nil {
+				return rollback(err)
+			}
+		}
+	}
+	for _, fw := range fws {
+		for _, rel := range fw.removals {
+			fw.removeEmptyParents(filepath.Join(fw.root, rel))
+		}
+	}
+	return nil
+}
+
+func (fw *FileWriter) isEmptyDir(dir string) bool {
+	infos, err := afero.ReadDir(fw.fs, dir)
+	return err == nil && len(infos) == 0
+}
+
+// validateStaged checks that every staged file was written in full
+func (fw *FileWriter) validateStaged() error {
+	for rel, contentHash := range fw.staged {
+		b, err := afero.ReadFile(fw.fs, fw.stagedPath(rel))
+		if err != nil {
+			return err
+		}
+		if hashBytes(b) != contentHash {
+			return fmt.Errorf("staged copy of %s does not match the generated content", rel)
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic writes content to a temporary file in the same directory as
+// path and renames it into place, so readers never observe a partial file.
+func writeFileAtomic(fs afero.Fs, path string, content []byte, mode os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := fs.MkdirAll(dir, generatedDirMode); err != nil {
+		return err
+	}
+	tmp, err := afero.TempFile(fs, dir, "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer fs.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := fs.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return fs.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestFileWriterAbort(t *testing.T) {
+	dir, err := ioutil.TempDir("", "writer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	root := filepath.Join(dir, "generated")
+	writeAndClose(t, root, map[string]string{"a/types.go": "package a"}, "v1")
+
+	fw, err := NewFileWriter(afero.NewOsFs(), root, WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"a/types.go": "package a // v2", "b/types.go": "package b"} {
+		if err := fw.WriteFile(filepath.Join(root, name), "v2", []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := fw.RemoveGenerated(filepath.Join(root, "a"), "removed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Abort(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(root, "a", "types.go"))
+	if err != nil {
+		t.Fatalf("Expected an aborted write to leave a/types.go in place: %s", err)
+	}
+	if string(b) != "package a" {
+		t.Errorf("Expected an aborted write not to modify a/types.go, saw %q", string(b))
+	}
+	if _, err := os.Stat(filepath.Join(root, "b")); !os.IsNotExist(err) {
+		t.Errorf("Expected an aborted write not to create b/")
+	}
+	expectNoStagingDir(t, afero.NewOsFs(), root)
+}
+
+func expectNoStagingDir(t *testing.T, fs afero.Fs, root string) {
+	infos, err := afero.ReadDir(fs, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range infos {
+		if strings.HasPrefix(info.Name(), StagingDirPrefix) {
+			t.Errorf("Expected the staging directory %s to be removed from %s", info.Name(), root)
+		}
+	}
+}
+
+func TestFileWriterStagesInsideRoot(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	leftover := filepath.Join("/out/generated", StagingDirPrefix+"123", "new", "a", "types.go")
+	if err := afero.WriteFile(fs, leftover, []byte("package a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fw, err := NewFileWriter(fs, "/out/generated", WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(fw.staging) != "/out/generated" {
+		t.Errorf("Expected to stage inside the root, saw %s", fw.staging)
+	}
+	if _, err := fs.Stat(leftover); !os.IsNotExist(err) {
+		t.Errorf("Expected the staging directory left by an earlier run to be removed")
+	}
+	if err := fw.WriteFile("/out/generated/a/types.go", "v1", []byte("package a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expectNoStagingDir(t, fs, "/out/generated")
+
+	// a root created for the run is removed again when it is aborted
+	fw, err = NewFileWriter(fs, "/new/generated", WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.WriteFile("/new/generated/a/types.go", "v1", []byte("package a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.Stat("/new"); !os.IsNotExist(err) {
+		t.Errorf("Expected an aborted write to remove the directories it created")
+	}
+}
+
+func TestCloseAll(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	outer, err := NewFileWriter(fs, "/provider", WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner, err := NewFileWriter(fs, "/provider/generated", WriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := outer.WriteFile("/provider/main.go", "v1", []byte("package main")); err != nil {
+		t.Fatal(err)
+	}
+	if err := inner.WriteFile("/provider/generated/a/types.go", "v1", []byte("package a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.Stat("/provider/main.go"); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be written before CloseAll")
+	}
+	if err := CloseAll(outer, inner); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"/provider/main.go", "/provider/generated/a/types.go", "/provider/" + ManifestFilename, "/provider/generated/" + ManifestFilename} {
+		if _, err := fs.Stat(p); err != nil {
+			t.Errorf("Expected %s to be written: %s", p, err)
+		}
+	}
+	expectNoStagingDir(t, fs, "/provider")
+	expectNoStagingDir(t, fs, "/provider/generated")
+}
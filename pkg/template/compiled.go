@@ -1,7 +1,6 @@
 package template
 
 import (
-	"fmt"
 	compiled "github.com/crossplane-contrib/terraform-provider-gen/internal/template/compiled"
 	tmpl "text/template"
 )
@@ -12,9 +11,9 @@ type compiledTemplateGetter struct {
 func (ctg *compiledTemplateGetter) Get(path string) (*tmpl.Template, error) {
 	cb, ok := compiled.TemplateDispatchMap[path]
 	if !ok {
-		return nil, fmt.Errorf("Compiled template not found for path %s", path)
+		return nil, &NotFoundError{Path: path, Source: "the compiled templates"}
 	}
-	return tmpl.New(path).Parse(cb())
+	return newTemplate(path).Parse(cb())
 }
 
 func NewCompiledTemplateGetter() TemplateGetter {
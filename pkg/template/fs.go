@@ -1,37 +1,29 @@
 package template
 
 import (
-	"bytes"
-	"io"
+	"errors"
 	"os"
 	"path"
+	"syscall"
 	tmpl "text/template"
+
+	"github.com/spf13/afero"
 )
 
 type fsTplGetter struct {
+	fs       afero.Fs
 	basepath string
 }
 
-func fileAsString(fname string) (string, error) {
-	fh, err := os.Open(fname)
-	defer fh.Close()
-	if err != nil {
-		return "", err
-	}
-	buf := new(bytes.Buffer)
-	_, err = io.Copy(buf, fh)
-	if err != nil {
-		return "", err
-	}
-	return buf.String(), nil
-}
-
 func (tg *fsTplGetter) Get(p string) (*tmpl.Template, error) {
-	str, err := fileAsString(path.Join(tg.basepath, p))
+	b, err := afero.ReadFile(tg.fs, path.Join(tg.basepath, p))
+	if os.IsNotExist(err) || errors.Is(err, syscall.ENOTDIR) {
+		return nil, &NotFoundError{Path: p, Source: tg.basepath}
+	}
 	if err != nil {
 		return nil, err
 	}
-	return tmpl.New(p).Parse(str)
+	return newTemplate(p).Parse(string(b))
 }
 
 // NewFSTemplateGetter returns a template getter that can find templates
@@ -39,7 +31,14 @@ func (tg *fsTplGetter) Get(p string) (*tmpl.Template, error) {
 // it assumes its own relative path within the project structure
 // see tests get_tests.go to help understand how it is used.
 func NewFSTemplateGetter(basepath string) TemplateGetter {
+	return NewAferoTemplateGetter(afero.NewOsFs(), basepath)
+}
+
+// NewAferoTemplateGetter returns a template getter which reads templates
+// from paths within basepath on fs.
+func NewAferoTemplateGetter(fs afero.Fs, basepath string) TemplateGetter {
 	return &fsTplGetter{
+		fs:       fs,
 		basepath: basepath,
 	}
 }
@@ -0,0 +1,13 @@
+package template
+
+import (
+	tmpl "text/template"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/template/funcs"
+)
+
+// newTemplate returns an empty template named path with funcs.FuncMap installed,
+// ready to parse
+func newTemplate(path string) *tmpl.Template {
+	return tmpl.New(path).Funcs(funcs.FuncMap())
+}
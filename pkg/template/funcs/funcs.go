@@ -0,0 +1,86 @@
+// Package funcs holds the functions available to the templates. It imports
+// none of the compiled templates, so that template-compile can use it to
+// check the templates even when the compiled templates do not build.
+package funcs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	tmpl "text/template"
+
+	"github.com/iancoleman/strcase"
+)
+
+// FuncMap returns the functions available to every template, both when it is
+// served by a TemplateGetter and when it is checked by template-compile:
+//
+//	camel, lowerCamel, snake, kebab  convert the case of a string, eg
+//	                                 {{ snake .ManagedResourceName }}
+//	plural                           pluralizes an English noun, eg policy -> policies
+//	join                             joins the elements of a list, eg {{ join ", " .Names }}
+//	indent                           indents every line of a string by a number of spaces
+//	lower, upper                     change the case of every letter
+func FuncMap() tmpl.FuncMap {
+	return tmpl.FuncMap{
+		"camel":      strcase.ToCamel,
+		"lowerCamel": strcase.ToLowerCamel,
+		"snake":      strcase.ToSnake,
+		"kebab":      strcase.ToKebab,
+		"plural":     Plural,
+		"join":       join,
+		"indent":     indent,
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+	}
+}
+
+// Plural returns the plural of an English noun, following the regular rules
+// used for the plural names of kubernetes kinds, so that Policy becomes
+// Policies. A noun in upper case gets an upper case suffix, eg BUS and BUSES.
+func Plural(noun string) string {
+	lower := strings.ToLower(noun)
+	upper := noun != lower && noun == strings.ToUpper(noun)
+	suffix := func(s string) string {
+		if upper {
+			return strings.ToUpper(s)
+		}
+		return s
+	}
+	switch {
+	case lower == "":
+		return noun
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return noun + suffix("es")
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsAny(lower[len(lower)-2:len(lower)-1], "aeiou"):
+		return noun[:len(noun)-1] + suffix("ies")
+	}
+	return noun + suffix("s")
+}
+
+// join joins the elements of list, which may be a slice of any type, with sep
+func join(sep string, list interface{}) (string, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join expects a list, not %T", list)
+	}
+	elems := make([]string, v.Len())
+	for i := range elems {
+		elems[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(elems, sep), nil
+}
+
+// indent prefixes every non-empty line of s with n spaces. Generated Go is
+// formatted afterwards, so this is mostly useful for comments and YAML.
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = pad + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,21 @@
+package funcs
+
+import "testing"
+
+func TestPlural(t *testing.T) {
+	cases := map[string]string{
+		"Bucket":  "Buckets",
+		"Policy":  "Policies",
+		"Gateway": "Gateways",
+		"Address": "Addresses",
+		"Box":     "Boxes",
+		"Branch":  "Branches",
+		"BUS":     "BUSES",
+		"":        "",
+	}
+	for noun, expected := range cases {
+		if actual := Plural(noun); actual != expected {
+			t.Errorf("Expected Plural(%q) to be %q, saw %q", noun, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package template
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestFuncMap(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	src := `{{ snake .Kind }} {{ kebab .Kind }} {{ lowerCamel .Kind }} {{ plural .Kind | lower }}
+{{ join ", " .Names }}
+{{ indent 2 "a\nb" }}`
+	if err := afero.WriteFile(fs, "/templates/funcs.tmpl", []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tpl, err := NewAferoTemplateGetter(fs, "/templates").Get("funcs.tmpl")
+	if err != nil {
+		t.Fatalf("Expected a template using FuncMap to parse: %s", err)
+	}
+	buf := new(bytes.Buffer)
+	data := struct {
+		Kind  string
+		Names []string
+	}{"SecurityPolicy", []string{"a", "b"}}
+	if err := tpl.Execute(buf, data); err != nil {
+		t.Fatal(err)
+	}
+	expected := "security_policy security-policy securityPolicy securitypolicies\na, b\n  a\n  b"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\nsaw:\n%s", expected, buf.String())
+	}
+}
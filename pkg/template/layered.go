@@ -0,0 +1,64 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	tmpl "text/template"
+
+	"github.com/spf13/afero"
+)
+
+// NotFoundError is returned by a TemplateGetter which has no template at Path
+type NotFoundError struct {
+	Path   string
+	Source string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("template %s not found in %s", e.Path, e.Source)
+}
+
+// IsNotFound reports whether err means a TemplateGetter has no template at the path
+func IsNotFound(err error) bool {
+	var nf *NotFoundError
+	return errors.As(err, &nf)
+}
+
+type layeredTemplateGetter struct {
+	layers []TemplateGetter
+}
+
+func (ltg *layeredTemplateGetter) Get(path string) (*tmpl.Template, error) {
+	for _, l := range ltg.layers {
+		t, err := l.Get(path)
+		if IsNotFound(err) {
+			continue
+		}
+		return t, err
+	}
+	return nil, &NotFoundError{Path: path, Source: "any template layer"}
+}
+
+// NewLayeredTemplateGetter returns a TemplateGetter which gets each template
+// from the first of layers which has it. A template which fails to parse is an
+// error, rather than falling back to a later layer.
+func NewLayeredTemplateGetter(layers ...TemplateGetter) TemplateGetter {
+	return &layeredTemplateGetter{layers: layers}
+}
+
+// NewUserTemplateGetter layers the template directories in dirs over the
+// compiled templates, so that any template can be customized by a file at the
+// same path in one of dirs, eg provider/cmd/provider/main.go.tpl. Earlier
+// directories take precedence over later ones.
+func NewUserTemplateGetter(fs afero.Fs, dirs ...string) (TemplateGetter, error) {
+	layers := make([]TemplateGetter, 0, len(dirs)+1)
+	for _, dir := range dirs {
+		isDir, err := afero.IsDir(fs, dir)
+		if err != nil || !isDir {
+			return nil, fmt.Errorf("template directory %s is not a directory", dir)
+		}
+		layers = append(layers, NewAferoTemplateGetter(fs, dir))
+	}
+	layers = append(layers, NewCompiledTemplateGetter())
+	return NewLayeredTemplateGetter(layers...), nil
+}
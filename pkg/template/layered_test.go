@@ -0,0 +1,65 @@
+package template
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestUserTemplateGetter(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"/team/provider/cmd/provider/main.go.tpl": "team main for {{ .Name }}",
+		"/org/provider/cmd/provider/main.go.tpl":  "org main",
+		"/org/pkg/generator/doc.go.tmpl":          "org doc",
+		"/org/pkg/generator/broken.go.tmpl":       "{{ .Unclosed",
+	}
+	for p, content := range files {
+		if err := afero.WriteFile(fs, p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tg, err := NewUserTemplateGetter(fs, "/team", "/org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"provider/cmd/provider/main.go.tpl": "team main for aws",
+		"pkg/generator/doc.go.tmpl":         "org doc",
+	}
+	for p, expected := range cases {
+		tpl, err := tg.Get(p)
+		if err != nil {
+			t.Fatalf("Unexpected error getting %s: %s", p, err)
+		}
+		buf := new(bytes.Buffer)
+		if err := tpl.Execute(buf, struct{ Name string }{"aws"}); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != expected {
+			t.Errorf("Expected %s to render %q, saw %q", p, expected, buf.String())
+		}
+	}
+
+	// templates missing from every directory fall back to the compiled set
+	tpl, err := tg.Get("pkg/generator/configure.go.tmpl")
+	if err != nil {
+		t.Fatalf("Expected configure.go.tmpl to fall back to the compiled templates: %s", err)
+	}
+	if !strings.Contains(tpl.Root.String(), "reconcilerConfigurer") {
+		t.Errorf("Expected the compiled configure.go.tmpl")
+	}
+
+	if _, err := tg.Get("pkg/generator/broken.go.tmpl"); err == nil || IsNotFound(err) {
+		t.Errorf("Expected a parse error from a broken user template, saw %v", err)
+	}
+	if _, err := tg.Get("pkg/generator/missing.go.tmpl"); !IsNotFound(err) {
+		t.Errorf("Expected a NotFoundError for a template found nowhere, saw %v", err)
+	}
+	if _, err := NewUserTemplateGetter(fs, "/missing"); err == nil {
+		t.Errorf("Expected an error for a template directory which does not exist")
+	}
+}
@@ -140,7 +140,7 @@ func (efr *decodeFnRenderer) ConversionFunc() string {
 	case cty.Bool:
 		return "ctwhy.ValueAsBool"
 	case cty.Number:
-		return "ctwhy.ValueAsInt64"
+		return numberDecodeFunc(efr.Field)
 	}
 	if efr.CtyType.IsObjectType() {
 		return "ctwhy.ValueAsObject"
@@ -151,6 +151,19 @@ func (efr *decodeFnRenderer) ConversionFunc() string {
 	panic(fmt.Sprintf("Unknown cty type in ConversionFunc(), cannot render convert function for: %s", efr.CtyType.FriendlyName()))
 }
 
+// numberDecodeFunc is the decode counterpart to numberEncodeFunc
+func numberDecodeFunc(f generator.Field) string {
+	decl := generator.AttributeTypeDeclaration(f)
+	switch f.AttributeField.Type {
+	case generator.AttributeTypeFloat64, generator.AttributeTypeFloat32:
+		return fmt.Sprintf("func(v cty.Value) %s { f, _ := v.AsBigFloat().Float64(); return %s(f) }", decl, decl)
+	case generator.AttributeTypeInt, generator.AttributeTypeInt32, generator.AttributeTypeInt16, generator.AttributeTypeInt8,
+		generator.AttributeTypeUint64, generator.AttributeTypeUint, generator.AttributeTypeUint32, generator.AttributeTypeUint16, generator.AttributeTypeUint8:
+		return fmt.Sprintf("func(v cty.Value) %s { return %s(ctwhy.ValueAsInt64(v)) }", decl, decl)
+	}
+	return "ctwhy.ValueAsInt64"
+}
+
 func (efr *decodeFnRenderer) CollectionConversionFunc() string {
 	if efr.CollectionType.IsSetType() {
 		return "ctwhy.ValueAsSet"
@@ -297,12 +310,26 @@ func {{.FuncName}}(p *{{.ParentType}}, vals map[string]cty.Value) {
 
 var decodeManagedResourceEntrypointTemplate = `type ctyDecoder struct{}
 
+// ctyPostDecoder may modify the {{ .TypeName}} decoded from the provider, see doc.go
+type ctyPostDecoder interface {
+	PostDecode(r *{{ .TypeName}}, v cty.Value) error
+}
+
 func (e *ctyDecoder) DecodeCty(mr resource.Managed, ctyValue cty.Value, schema *providers.Schema) (resource.Managed, error) {
 	r, ok := mr.(*{{ .TypeName}})
 	if !ok {
 		return nil, fmt.Errorf("DecodeCty received a resource.Managed value that does not assert to the expected type")
 	}
-	return {{.DecodeFnName}}(r, ctyValue)
+	decoded, err := {{.DecodeFnName}}(r, ctyValue)
+	if err != nil {
+		return nil, err
+	}
+	if hook, ok := interface{}(e).(ctyPostDecoder); ok {
+		if err := hook.PostDecode(decoded.(*{{ .TypeName}}), ctyValue); err != nil {
+			return nil, err
+		}
+	}
+	return decoded, nil
 }
 
 func {{.DecodeFnName}}(prev *{{.TypeName}}, ctyValue cty.Value) (resource.Managed, error) {
@@ -98,6 +98,7 @@ func (bt *backTracker) encodeFnRenderer(funcPrefix, receivedType string, f gener
 		Children:           f.Fields,
 		CtyType:            bt.ctyType,
 		CollectionType:     bt.collectionType,
+		Field:              f,
 	}
 }
 
@@ -109,6 +110,7 @@ type encodeFnRenderer struct {
 	Children           []generator.Field
 	CtyType            cty.Type
 	CollectionType     *cty.Type
+	Field              generator.Field
 }
 
 func renderPrimitiveType(efr *encodeFnRenderer, template string) string {
@@ -141,7 +143,7 @@ func (efr *encodeFnRenderer) ConversionFunc() string {
 	case cty.Bool:
 		return "cty.BoolVal"
 	case cty.Number:
-		return "cty.NumberIntVal"
+		return numberEncodeFunc(efr.Field)
 	}
 	if efr.CtyType.IsObjectType() {
 		return "cty.ObjectVal"
@@ -152,6 +154,26 @@ func (efr *encodeFnRenderer) ConversionFunc() string {
 	panic(fmt.Sprintf("Unknown cty type in ConversionFunc(), cannot render convert function for: %s", efr.CtyType.FriendlyName()))
 }
 
+// numberEncodeFunc picks the cty constructor matching the go type of a number field.
+// Fields can be retyped away from the default int64 by a field override, in which case
+// a func literal is rendered to convert the value to the type the constructor expects.
+func numberEncodeFunc(f generator.Field) string {
+	decl := generator.AttributeTypeDeclaration(f)
+	switch f.AttributeField.Type {
+	case generator.AttributeTypeFloat64:
+		return "cty.NumberFloatVal"
+	case generator.AttributeTypeFloat32:
+		return fmt.Sprintf("func(v %s) cty.Value { return cty.NumberFloatVal(float64(v)) }", decl)
+	case generator.AttributeTypeUint64:
+		return "cty.NumberUIntVal"
+	case generator.AttributeTypeUint, generator.AttributeTypeUint32, generator.AttributeTypeUint16, generator.AttributeTypeUint8:
+		return fmt.Sprintf("func(v %s) cty.Value { return cty.NumberUIntVal(uint64(v)) }", decl)
+	case generator.AttributeTypeInt, generator.AttributeTypeInt32, generator.AttributeTypeInt16, generator.AttributeTypeInt8:
+		return fmt.Sprintf("func(v %s) cty.Value { return cty.NumberIntVal(int64(v)) }", decl)
+	}
+	return "cty.NumberIntVal"
+}
+
 func (efr *encodeFnRenderer) CollectionConversionFunc() string {
 	if efr.CollectionType.IsSetType() {
 		return "cty.SetVal"
@@ -263,12 +285,21 @@ var containerCollectionSingletonTypeTemplate = `func {{.FuncName}}(p {{.ParentTy
 
 var managedResourceEntrypointTemplate = `type ctyEncoder struct{}
 
+// ctyPostEncoder may replace the value encoded from a {{ .TypeName}}, see doc.go
+type ctyPostEncoder interface {
+	PostEncode(r *{{ .TypeName}}, v cty.Value) (cty.Value, error)
+}
+
 func (e *ctyEncoder) EncodeCty(mr resource.Managed, schema *providers.Schema) (cty.Value, error) {
 	r, ok := mr.(*{{ .TypeName}})
 	if !ok {
 		return cty.NilVal, fmt.Errorf("EncodeType received a resource.Managed value which is not a {{ .TypeName}}.")
 	}
-	return {{.EncodeFnName}}(*r), nil
+	v := {{.EncodeFnName}}(*r)
+	if hook, ok := interface{}(e).(ctyPostEncoder); ok {
+		return hook.PostEncode(r, v)
+	}
+	return v, nil
 }
 
 func {{.EncodeFnName}}(r {{.TypeName}}) cty.Value {
@@ -0,0 +1,131 @@
+package translate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// IRAPIVersion is the version of the serialized form of a ManagedResource
+	IRAPIVersion = "terraform-provider-gen.crossplane.io/v1alpha1"
+	// IRKind is the kind of the serialized form of a ManagedResource
+	IRKind = "ManagedResource"
+)
+
+// IR is the serialized form of a ManagedResource, the intermediate
+// representation rendered by the templates. It can be written as JSON or
+// YAML, edited, and loaded again in place of translating the schema.
+type IR struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Resource is the terraform name of the resource, eg aws_s3_bucket
+	Resource        string                     `json:"resource"`
+	ManagedResource *generator.ManagedResource `json:"managedResource"`
+}
+
+var nestingModeNames = map[configschema.NestingMode]string{
+	configschema.NestingSingle: "single",
+	configschema.NestingGroup:  "group",
+	configschema.NestingList:   "list",
+	configschema.NestingSet:    "set",
+	configschema.NestingMap:    "map",
+}
+
+// MarshalIR serializes the ManagedResource of the terraform resource named
+// resource, as YAML when format is "yaml" and otherwise as indented JSON.
+func MarshalIR(resource string, mr *generator.ManagedResource, format string) ([]byte, error) {
+	ir := IR{
+		APIVersion:      IRAPIVersion,
+		Kind:            IRKind,
+		Resource:        resource,
+		ManagedResource: mr,
+	}
+	switch format {
+	case "yaml":
+		return yaml.Marshal(ir)
+	case "json":
+		b, err := json.MarshalIndent(ir, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	}
+	return nil, fmt.Errorf("unknown IR format %q, expected json or yaml", format)
+}
+
+// UnmarshalIR loads an IR written by MarshalIR, as either JSON or YAML,
+// restoring the function generators and namer of its ManagedResource so that
+// it is ready to be rendered. Unknown keys are rejected, since they are
+// usually a typo in a hand edited file.
+func UnmarshalIR(b []byte) (*IR, error) {
+	ir := &IR{}
+	if err := yaml.UnmarshalStrict(b, ir); err != nil {
+		return nil, err
+	}
+	if ir.APIVersion != IRAPIVersion || ir.Kind != IRKind {
+		return nil, fmt.Errorf("expected apiVersion %s and kind %s, saw %s and %s", IRAPIVersion, IRKind, ir.APIVersion, ir.Kind)
+	}
+	if ir.Resource == "" || ir.ManagedResource == nil {
+		return nil, fmt.Errorf("resource and managedResource are required")
+	}
+	mr := ir.ManagedResource
+	mr.WithNamer(generator.NewDefaultNamer(mr.Name))
+	if err := RestoreGenerators(mr); err != nil {
+		return nil, err
+	}
+	return ir, nil
+}
+
+// RestoreGenerators sets the encode, decode and merge function generators of
+// every field of mr from the FieldSchema recorded when it was translated.
+func RestoreGenerators(mr *generator.ManagedResource) error {
+	fail := generator.NewMultiError("cannot restore the function generators of " + mr.Name + ":")
+	for _, f := range []*generator.Field{&mr.Parameters, &mr.Observation} {
+		restoreFieldGenerators(f.Name, f.Fields, fail)
+	}
+	if len(fail.Errors()) > 0 {
+		return fail
+	}
+	return nil
+}
+
+func restoreFieldGenerators(path string, fields []generator.Field, fail generator.MultiError) {
+	for i := range fields {
+		f := &fields[i]
+		fieldPath := path + "." + f.Name
+		switch {
+		case f.Schema == nil:
+			fail.Append(fmt.Errorf("%s has no schema", fieldPath))
+		case f.Schema.Nesting != "":
+			mode, ok := nestingMode(f.Schema.Nesting)
+			if !ok {
+				fail.Append(fmt.Errorf("%s has an unknown nesting mode %q", fieldPath, f.Schema.Nesting))
+				break
+			}
+			block := &configschema.NestedBlock{Nesting: mode}
+			f.EncodeFnGenerator = NewBlockEncodeFnGenerator(f.Schema.Name, block)
+			f.DecodeFnGenerator = NewBlockDecodeFnGenerator(f.Schema.Name, block)
+			f.MergeFnGenerator = NewBlockMergeFnGenerator(f.Schema.Name, block)
+		case f.Schema.Type != nil:
+			f.EncodeFnGenerator = NewAttributeEncodeFnGenerator(f.Schema.Name, *f.Schema.Type)
+			f.DecodeFnGenerator = NewAttributeDecodeFnGenerator(f.Schema.Name, *f.Schema.Type)
+			f.MergeFnGenerator = NewAttributeMergeFnGenerator(f.Schema.Name, *f.Schema.Type)
+		default:
+			fail.Append(fmt.Errorf("%s has neither a type nor a nesting mode in its schema", fieldPath))
+		}
+		restoreFieldGenerators(fieldPath, f.Fields, fail)
+	}
+}
+
+func nestingMode(name string) (configschema.NestingMode, bool) {
+	for mode, n := range nestingModeNames {
+		if n == name {
+			return mode, true
+		}
+	}
+	return 0, false
+}
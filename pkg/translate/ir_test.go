@@ -0,0 +1,96 @@
+package translate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	tpl "github.com/crossplane-contrib/terraform-provider-gen/pkg/template"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func testFixtureNestedBlock() *configschema.NestedBlock {
+	return &configschema.NestedBlock{
+		Nesting: configschema.NestingList,
+		Block: configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"port": {Optional: true, Type: cty.Number},
+			},
+			BlockTypes: map[string]*configschema.NestedBlock{},
+		},
+	}
+}
+
+// renderRuntime renders the encoders, decoders and mergers of mr
+func renderRuntime(t *testing.T, mr *generator.ManagedResource) string {
+	tg := tpl.NewCompiledTemplateGetter()
+	rendered := make([]string, 0)
+	for _, generate := range []func(*generator.ManagedResource, tpl.TemplateGetter) (string, error){GenerateEncoders, GenerateDecoders, GenerateMergers} {
+		s, err := generate(mr, tg)
+		if err != nil {
+			t.Fatalf("Unexpected error rendering %s: %s", mr.Name, err)
+		}
+		rendered = append(rendered, s)
+	}
+	return strings.Join(rendered, "\n")
+}
+
+func TestIRRoundTrip(t *testing.T) {
+	s := testFixtureFlatBlock()
+	s.Block.BlockTypes["listener"] = testFixtureNestedBlock()
+	mr := SchemaToManagedResource("TestResource", "github.com/crossplane-contrib/fake", s)
+	expected := renderRuntime(t, mr)
+
+	for _, format := range []string{"json", "yaml"} {
+		b, err := MarshalIR("test_resource", mr, format)
+		if err != nil {
+			t.Fatalf("%s: unexpected error from MarshalIR: %s", format, err)
+		}
+		ir, err := UnmarshalIR(b)
+		if err != nil {
+			t.Fatalf("%s: unexpected error from UnmarshalIR: %s\n%s", format, err, string(b))
+		}
+		if ir.Resource != "test_resource" {
+			t.Errorf("%s: expected resource test_resource, saw %s", format, ir.Resource)
+		}
+		if actual := renderRuntime(t, ir.ManagedResource); actual != expected {
+			t.Errorf("%s: expected the loaded IR to render like the translated schema, saw:\n%s\nexpected:\n%s", format, actual, expected)
+		}
+	}
+}
+
+func TestUnmarshalIRErrors(t *testing.T) {
+	cases := map[string]string{
+		"expected apiVersion": `apiVersion: terraform-provider-gen.crossplane.io/v1alpha1
+kind: Resource
+resource: test_resource
+managedResource: {name: TestResource, packagePath: fake}
+`,
+		`unknown field "paramaters"`: `apiVersion: terraform-provider-gen.crossplane.io/v1alpha1
+kind: ManagedResource
+resource: test_resource
+managedResource: {name: TestResource, packagePath: fake, paramaters: {}}
+`,
+		"ForProvider.Added has no schema": `apiVersion: terraform-provider-gen.crossplane.io/v1alpha1
+kind: ManagedResource
+resource: test_resource
+managedResource:
+  name: TestResource
+  packagePath: fake
+  parameters:
+    name: ForProvider
+    type: Struct
+    fields:
+    - name: Added
+      type: Attribute
+      attributeField: {type: String}
+`,
+	}
+	for expected, ir := range cases {
+		_, err := UnmarshalIR([]byte(ir))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected an error from UnmarshalIR containing %q, saw %v", expected, err)
+		}
+	}
+}
@@ -333,6 +333,11 @@ func {{.FuncName}}(ksp *[]{{.ParentType}}, psp *[]{{.ParentType}}, md *plugin.Me
 var mergeManagedResourceEntrypointTemplate = `//mergeManagedResourceEntrypointTemplate
 type resourceMerger struct{}
 
+// resourcePostMerger may modify the merged {{ .TypeName }} and MergeDescription, see doc.go
+type resourcePostMerger interface {
+	PostMerge(kube, prov *{{ .TypeName }}, md *plugin.MergeDescription)
+}
+
 func (r *resourceMerger) MergeResources(kube resource.Managed, prov resource.Managed) plugin.MergeDescription {
 	k := kube.(*{{ .TypeName }})
 	p := prov.(*{{ .TypeName }})
@@ -349,6 +354,9 @@ func (r *resourceMerger) MergeResources(kube resource.Managed, prov resource.Man
 		}
 	}
 	md.AnyFieldUpdated = anyChildUpdated
+	if hook, ok := interface{}(r).(resourcePostMerger); ok {
+		hook.PostMerge(k, p, md)
+	}
 	return *md
 }`
 
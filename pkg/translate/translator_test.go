@@ -1,9 +1,11 @@
 package translate
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/template"
 	"github.com/hashicorp/terraform/configs/configschema"
 	"github.com/hashicorp/terraform/providers"
 	"github.com/iancoleman/strcase"
@@ -108,3 +110,22 @@ func TestSchemaToManagedResourceRender(t *testing.T) {
 		t.Errorf("expected ManagedResource.Name=%s, actual=%s", mr.Namer().TypeName(), mr.Name)
 	}
 }
+
+func TestEntrypointsCallOptionalHooks(t *testing.T) {
+	mr := SchemaToManagedResource("TestResource", "github.com/crossplane/provider-terraform-aws/generated/test/v1alpha1", testFixtureFlatBlock())
+	tg := template.NewCompiledTemplateGetter()
+	generators := map[string]func(*generator.ManagedResource, template.TemplateGetter) (string, error){
+		"interface{}(e).(ctyPostEncoder)":     GenerateEncoders,
+		"interface{}(e).(ctyPostDecoder)":     GenerateDecoders,
+		"interface{}(r).(resourcePostMerger)": GenerateMergers,
+	}
+	for call, generate := range generators {
+		src, err := generate(mr, tg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(src, call) {
+			t.Errorf("Expected the generated entrypoint to call its optional hook with %s, saw:\n%s", call, src)
+		}
+	}
+}
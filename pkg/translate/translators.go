@@ -50,6 +50,7 @@ func NewFieldBuilder(name string, ctyType cty.Type) *FieldBuilder {
 			EncodeFnGenerator: encFnGen,
 			DecodeFnGenerator: decFnGen,
 			MergeFnGenerator:  mergeFnGen,
+			Schema:            &generator.FieldSchema{Name: name, Type: &ctyType},
 		},
 	}
 }
@@ -251,6 +252,7 @@ func NestedBlockFields(blocks map[string]*configschema.NestedBlock, packagePath,
 		f.EncodeFnGenerator = NewBlockEncodeFnGenerator(name, block)
 		f.DecodeFnGenerator = NewBlockDecodeFnGenerator(name, block)
 		f.MergeFnGenerator = NewBlockMergeFnGenerator(name, block)
+		f.Schema = &generator.FieldSchema{Name: name, Nesting: nestingModeNames[block.Nesting]}
 
 		sp := appendToSchemaPath(schemaPath, f.Name)
 		for n, attr := range block.Attributes {
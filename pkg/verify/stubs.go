@@ -0,0 +1,466 @@
+package verify
+
+// stubSources holds stub versions of the packages imported by generated code,
+// keyed by import path. They declare the identifiers the templates use, with
+// the signatures of the real packages, so that generated code can be type
+// checked without fetching crossplane-runtime, terraform-runtime or their
+// dependencies. Where the real types would require methods which are only
+// added by later code generators (angryjet, controller-gen), the stubs accept
+// anything which embeds the apimachinery object metadata.
+var stubSources = map[string]string{
+	"github.com/zclconf/go-cty/cty": `package cty
+
+import "math/big"
+
+type Type struct {
+	name string
+	elem *Type
+	attrs map[string]Type
+}
+
+var (
+	String            = Type{name: "string"}
+	Number            = Type{name: "number"}
+	Bool              = Type{name: "bool"}
+	DynamicPseudoType = Type{name: "dynamic"}
+	EmptyObject       = Type{name: "object"}
+	EmptyTuple        = Type{name: "tuple"}
+)
+
+func List(elem Type) Type                      { return Type{name: "list", elem: &elem} }
+func Set(elem Type) Type                       { return Type{name: "set", elem: &elem} }
+func Map(elem Type) Type                       { return Type{name: "map", elem: &elem} }
+func Object(attrTypes map[string]Type) Type    { return Type{name: "object", attrs: attrTypes} }
+func Tuple(elemTypes []Type) Type              { return Type{name: "tuple"} }
+
+func (t Type) Equals(other Type) bool { return t.name == other.name }
+func (t Type) FriendlyName() string   { return t.name }
+func (t Type) GoString() string       { return t.name }
+
+type Value struct {
+	ty Type
+	v  interface{}
+}
+
+var (
+	NilVal   = Value{}
+	True     = BoolVal(true)
+	False    = BoolVal(false)
+	EmptyObjectVal = Value{ty: EmptyObject}
+)
+
+func StringVal(v string) Value                 { return Value{ty: String, v: v} }
+func NumberIntVal(v int64) Value               { return Value{ty: Number, v: v} }
+func NumberUIntVal(v uint64) Value             { return Value{ty: Number, v: v} }
+func NumberFloatVal(v float64) Value           { return Value{ty: Number, v: v} }
+func NumberVal(v *big.Float) Value             { return Value{ty: Number, v: v} }
+func BoolVal(v bool) Value                     { return Value{ty: Bool, v: v} }
+func ListVal(vals []Value) Value               { return Value{v: vals} }
+func ListValEmpty(element Type) Value          { return Value{ty: List(element)} }
+func SetVal(vals []Value) Value                { return Value{v: vals} }
+func SetValEmpty(element Type) Value           { return Value{ty: Set(element)} }
+func MapVal(vals map[string]Value) Value       { return Value{v: vals} }
+func MapValEmpty(element Type) Value           { return Value{ty: Map(element)} }
+func ObjectVal(attrs map[string]Value) Value   { return Value{v: attrs} }
+func TupleVal(elems []Value) Value             { return Value{v: elems} }
+func NullVal(t Type) Value                     { return Value{ty: t} }
+func UnknownVal(t Type) Value                  { return Value{ty: t} }
+
+func (val Value) Type() Type                        { return val.ty }
+func (val Value) IsNull() bool                      { return val.v == nil }
+func (val Value) IsKnown() bool                     { return true }
+func (val Value) IsWhollyKnown() bool               { return true }
+func (val Value) True() bool                        { return false }
+func (val Value) AsString() string                  { return "" }
+func (val Value) AsBigFloat() *big.Float            { return new(big.Float) }
+func (val Value) AsValueMap() map[string]Value      { return nil }
+func (val Value) AsValueSlice() []Value             { return nil }
+func (val Value) AsValueSet() interface{}           { return nil }
+func (val Value) LengthInt() int                    { return 0 }
+func (val Value) GetAttr(name string) Value         { return Value{} }
+func (val Value) Index(key Value) Value             { return Value{} }
+func (val Value) Equals(other Value) Value          { return Value{} }
+func (val Value) GoString() string                  { return "" }
+`,
+
+	"github.com/hashicorp/terraform/providers": `package providers
+
+type Schema struct {
+	Version int64
+	Block   interface{}
+}
+
+type GetSchemaResponse struct {
+	Provider      Schema
+	ResourceTypes map[string]Schema
+	DataSources   map[string]Schema
+}
+`,
+
+	"k8s.io/apimachinery/pkg/runtime/schema": `package schema
+
+type GroupVersion struct {
+	Group   string
+	Version string
+}
+
+func (gv GroupVersion) String() string                        { return gv.Group + "/" + gv.Version }
+func (gv GroupVersion) WithKind(kind string) GroupVersionKind { return GroupVersionKind{Group: gv.Group, Version: gv.Version, Kind: kind} }
+
+type GroupKind struct {
+	Group string
+	Kind  string
+}
+
+func (gk GroupKind) String() string { return gk.Kind + "." + gk.Group }
+
+type GroupVersionKind struct {
+	Group   string
+	Version string
+	Kind    string
+}
+
+func (gvk GroupVersionKind) GroupKind() GroupKind       { return GroupKind{Group: gvk.Group, Kind: gvk.Kind} }
+func (gvk GroupVersionKind) GroupVersion() GroupVersion { return GroupVersion{Group: gvk.Group, Version: gvk.Version} }
+func (gvk GroupVersionKind) String() string             { return gvk.Kind }
+`,
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1": `package v1
+
+type TypeMeta struct {
+	Kind       string
+	APIVersion string
+}
+
+type ObjectMeta struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+func (meta *ObjectMeta) GetName() string                            { return meta.Name }
+func (meta *ObjectMeta) SetName(name string)                        { meta.Name = name }
+func (meta *ObjectMeta) GetNamespace() string                       { return meta.Namespace }
+func (meta *ObjectMeta) SetNamespace(namespace string)              { meta.Namespace = namespace }
+func (meta *ObjectMeta) GetLabels() map[string]string               { return meta.Labels }
+func (meta *ObjectMeta) SetLabels(labels map[string]string)         { meta.Labels = labels }
+func (meta *ObjectMeta) GetAnnotations() map[string]string          { return meta.Annotations }
+func (meta *ObjectMeta) SetAnnotations(annotations map[string]string) { meta.Annotations = annotations }
+
+// Object is implemented by every type embedding ObjectMeta
+type Object interface {
+	GetName() string
+	SetName(name string)
+	GetNamespace() string
+	SetNamespace(namespace string)
+	GetLabels() map[string]string
+	SetLabels(labels map[string]string)
+	GetAnnotations() map[string]string
+	SetAnnotations(annotations map[string]string)
+}
+
+type ListMeta struct {
+	ResourceVersion string
+	Continue        string
+}
+
+type Time struct{}
+`,
+
+	"github.com/crossplane/crossplane-runtime/apis/common/v1": `package v1
+
+type Reference struct {
+	Name string
+}
+
+type SecretKeySelector struct {
+	Name      string
+	Namespace string
+	Key       string
+}
+
+type SecretReference struct {
+	Name      string
+	Namespace string
+}
+
+type CredentialsSource string
+
+const CredentialsSourceSecret CredentialsSource = "Secret"
+
+type DeletionPolicy string
+
+type ResourceSpec struct {
+	WriteConnectionSecretToReference *SecretReference
+	ProviderConfigReference          *Reference
+	DeletionPolicy                   DeletionPolicy
+}
+
+type Condition struct {
+	Type   string
+	Status string
+}
+
+type ConditionedStatus struct {
+	Conditions []Condition
+}
+
+type ResourceStatus struct {
+	ConditionedStatus
+}
+
+type ProviderConfigSpec struct {
+	Credentials ProviderCredentials
+}
+
+type ProviderCredentials struct {
+	Source          CredentialsSource
+	SecretRef       *SecretKeySelector
+}
+
+type ProviderConfigStatus struct {
+	ConditionedStatus
+	Users int64
+}
+
+type ProviderConfigUsage struct {
+	ProviderConfigReference Reference
+	ResourceReference       TypedReference
+}
+
+type TypedReference struct {
+	APIVersion string
+	Kind       string
+	Name       string
+}
+`,
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource": `package resource
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Managed is satisfied by any generated resource; the methods angryjet adds
+// to the real interface are not generated yet when the code is verified.
+type Managed interface {
+	metav1.Object
+}
+
+type ManagedKind schema.GroupVersionKind
+
+type ProviderConfigKinds struct {
+	Config    schema.GroupVersionKind
+	UsageList schema.GroupVersionKind
+}
+
+type ProviderConfigUsageTracker struct{}
+
+func NewProviderConfigUsageTracker(c interface{}, of interface{}) *ProviderConfigUsageTracker {
+	return &ProviderConfigUsageTracker{}
+}
+`,
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta": `package meta
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+const AnnotationKeyExternalName = "crossplane.io/external-name"
+
+func GetExternalName(o metav1.Object) string         { return o.GetAnnotations()[AnnotationKeyExternalName] }
+func SetExternalName(o metav1.Object, name string)   {}
+`,
+
+	"github.com/crossplane/crossplane-runtime/pkg/event": `package event
+
+type Recorder interface{}
+
+func NewAPIRecorder(r interface{}) Recorder { return nil }
+`,
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging": `package logging
+
+type Logger interface {
+	Info(msg string, keysAndValues ...interface{})
+	Debug(msg string, keysAndValues ...interface{})
+	WithValues(keysAndValues ...interface{}) Logger
+}
+
+func NewLogrLogger(l interface{}) Logger { return nil }
+func NewNopLogger() Logger              { return nil }
+`,
+
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed": `package managed
+
+import (
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+type Reconciler struct{}
+
+type ReconcilerOption func(*Reconciler)
+
+type Initializer interface{}
+
+type ExternalConnecter interface{}
+
+func ControllerName(kind string) string { return "managed/" + kind }
+
+func NewReconciler(m interface{}, of resource.ManagedKind, o ...ReconcilerOption) *Reconciler {
+	return &Reconciler{}
+}
+
+func WithInitializers(i ...Initializer) ReconcilerOption     { return nil }
+func WithTimeout(duration time.Duration) ReconcilerOption    { return nil }
+func WithExternalConnecter(c ExternalConnecter) ReconcilerOption { return nil }
+func WithLogger(l logging.Logger) ReconcilerOption           { return nil }
+func WithRecorder(er event.Recorder) ReconcilerOption        { return nil }
+`,
+
+	"sigs.k8s.io/controller-runtime": `package controllerruntime
+
+type Client interface{}
+
+type EventRecorder interface{}
+
+type Manager interface {
+	GetClient() Client
+	GetEventRecorderFor(name string) EventRecorder
+}
+
+type Options struct {
+	SyncPeriod interface{}
+}
+
+type Builder struct{}
+
+func NewControllerManagedBy(m Manager) *Builder            { return &Builder{} }
+func (blder *Builder) Named(name string) *Builder           { return blder }
+func (blder *Builder) For(object interface{}) *Builder      { return blder }
+func (blder *Builder) Complete(r interface{}) error         { return nil }
+`,
+
+	"sigs.k8s.io/controller-runtime/pkg/scheme": `package scheme
+
+import "k8s.io/apimachinery/pkg/runtime/schema"
+
+type Builder struct {
+	GroupVersion schema.GroupVersion
+}
+
+func (bld *Builder) Register(object ...interface{}) *Builder { return bld }
+`,
+
+	"github.com/crossplane-contrib/terraform-runtime/pkg/client": `package client
+
+type ProviderPool struct{}
+
+type Provider struct{}
+
+type RuntimeOptions struct{}
+
+func NewRuntimeOptions() *RuntimeOptions { return &RuntimeOptions{} }
+`,
+
+	"github.com/crossplane-contrib/terraform-runtime/pkg/controller": `package controller
+
+import (
+	"github.com/crossplane-contrib/terraform-runtime/pkg/client"
+	"github.com/crossplane-contrib/terraform-runtime/pkg/plugin"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+type Connector struct {
+	KubeClient  ctrl.Client
+	PluginIndex *plugin.Index
+	Logger      logging.Logger
+	Pool        *client.ProviderPool
+}
+`,
+
+	"github.com/crossplane-contrib/terraform-runtime/pkg/plugin": `package plugin
+
+import (
+	"github.com/crossplane-contrib/terraform-runtime/pkg/client"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/zclconf/go-cty/cty"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+type MergeDescription struct {
+	LateInitializedSpec bool
+	StatusUpdated       bool
+	NeedsProviderUpdate bool
+	AnnotationsUpdated  bool
+	AnyFieldUpdated     bool
+}
+
+type ReconcilerConfigurer interface {
+	ConfigureReconciler(mgr ctrl.Manager, l logging.Logger, idx *Index, pool *client.ProviderPool) error
+}
+
+type ResourceMerger interface {
+	MergeResources(kube resource.Managed, prov resource.Managed) MergeDescription
+}
+
+type CtyEncoder interface {
+	EncodeCty(mr resource.Managed, schema *providers.Schema) (cty.Value, error)
+}
+
+type CtyDecoder interface {
+	DecodeCty(mr resource.Managed, ctyValue cty.Value, schema *providers.Schema) (resource.Managed, error)
+}
+
+type Implementation struct {
+	GVK                   schema.GroupVersionKind
+	TerraformResourceName string
+	SchemeBuilder         *scheme.Builder
+	ReconcilerConfigurer  ReconcilerConfigurer
+	ResourceMerger        ResourceMerger
+	CtyEncoder            CtyEncoder
+	CtyDecoder            CtyDecoder
+}
+
+type ProviderInit struct{}
+
+type Index struct{}
+
+type Indexer struct{}
+
+func NewIndexer() *Indexer { return &Indexer{} }
+
+func CompareStringSlices(a, b []string) bool              { return false }
+func CompareInt64Slices(a, b []int64) bool                { return false }
+func CompareFloat64Slices(a, b []float64) bool            { return false }
+func CompareBoolSlices(a, b []bool) bool                  { return false }
+func CompareMapString(a, b map[string]string) bool        { return false }
+func CompareMapInt64(a, b map[string]int64) bool          { return false }
+func CompareMapFloat64(a, b map[string]float64) bool      { return false }
+func CompareMapBool(a, b map[string]bool) bool            { return false }
+`,
+
+	"github.com/crossplane-contrib/terraform-runtime/pkg/plugin/cty": `package cty
+
+import "github.com/zclconf/go-cty/cty"
+
+func ValueAsString(value cty.Value) string                 { return "" }
+func ValueAsBool(value cty.Value) bool                     { return false }
+func ValueAsInt64(value cty.Value) int64                   { return 0 }
+func ValueAsFloat64(value cty.Value) float64               { return 0 }
+func ValueAsList(value cty.Value) []cty.Value              { return nil }
+func ValueAsSet(value cty.Value) []cty.Value               { return nil }
+func ValueAsMap(value cty.Value) map[string]cty.Value      { return nil }
+func ValueAsObject(value cty.Value) map[string]cty.Value   { return nil }
+`,
+}
@@ -0,0 +1,173 @@
+package verify
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+)
+
+// Checker type checks generated packages in-process with go/types, resolving
+// the standard library from source and every other import from stubSources.
+// A Checker can be shared by concurrent calls to Check.
+type Checker struct {
+	fset *token.FileSet
+	// mu guards std and imported, the source importer is not safe for concurrent use
+	mu       sync.Mutex
+	std      types.Importer
+	imported map[string]*types.Package
+}
+
+func NewChecker() *Checker {
+	fset := token.NewFileSet()
+	return &Checker{
+		fset:     fset,
+		std:      importer.ForCompiler(fset, "source", nil),
+		imported: make(map[string]*types.Package),
+	}
+}
+
+// Import implements types.Importer
+func (c *Checker) Import(path string) (*types.Package, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.importLocked(path)
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+func (c *Checker) importLocked(path string) (*types.Package, error) {
+	if pkg, ok := c.imported[path]; ok {
+		return pkg, nil
+	}
+	src, ok := stubSources[path]
+	if !ok {
+		pkg, err := c.std.Import(path)
+		if err != nil {
+			return nil, fmt.Errorf("no stub for %s: %s", path, err)
+		}
+		c.imported[path] = pkg
+		return pkg, nil
+	}
+	f, err := parser.ParseFile(c.fset, path+"/stub.go", src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("stub for %s: %s", path, err)
+	}
+	// stubs import each other, so resolve them without taking the lock again
+	conf := types.Config{Importer: importerFunc(c.importLocked)}
+	pkg, err := conf.Check(path, c.fset, []*ast.File{f}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("stub for %s: %s", path, err)
+	}
+	c.imported[path] = pkg
+	return pkg, nil
+}
+
+// Check type checks the package made up of files, which maps file names to
+// their source. DeepCopy methods, which controller-gen adds to the real
+// package, are declared for any struct type that lacks one. Every type error
+// is returned in a generator.MultiError.
+func (c *Checker) Check(importPath string, files map[string][]byte) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parsed := make([]*ast.File, 0, len(files)+1)
+	for _, name := range names {
+		f, err := parser.ParseFile(c.fset, name, files[name], 0)
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, f)
+	}
+	if deepcopy := deepCopyStubs(parsed); deepcopy != nil {
+		f, err := parser.ParseFile(c.fset, "zz_generated.deepcopy.go", deepcopy, 0)
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, f)
+	}
+
+	me := generator.NewMultiError(fmt.Sprintf("type errors in %s:", importPath))
+	conf := types.Config{
+		Importer: c,
+		Error: func(err error) {
+			if terr, ok := err.(types.Error); ok {
+				pos := terr.Fset.Position(terr.Pos)
+				err = fmt.Errorf("%s:%d:%d: %s", filepath.Base(pos.Filename), pos.Line, pos.Column, terr.Msg)
+			}
+			me.Append(err)
+		},
+	}
+	// errors are collected by conf.Error, the returned error is only the first of them
+	_, _ = conf.Check(importPath, c.fset, parsed, nil)
+	if len(me.Errors()) > 0 {
+		return me
+	}
+	return nil
+}
+
+// deepCopyStubs declares a DeepCopy method for every struct type in files
+// which does not already have one, returning nil when none are needed.
+func deepCopyStubs(files []*ast.File) []byte {
+	if len(files) == 0 {
+		return nil
+	}
+	structs := make([]string, 0)
+	hasDeepCopy := make(map[string]bool)
+	for _, f := range files {
+		for _, d := range f.Decls {
+			switch decl := d.(type) {
+			case *ast.GenDecl:
+				for _, s := range decl.Specs {
+					if ts, ok := s.(*ast.TypeSpec); ok {
+						if _, ok := ts.Type.(*ast.StructType); ok {
+							structs = append(structs, ts.Name.Name)
+						}
+					}
+				}
+			case *ast.FuncDecl:
+				if decl.Recv != nil && decl.Name.Name == "DeepCopy" && len(decl.Recv.List) == 1 {
+					hasDeepCopy[receiverName(decl.Recv.List[0].Type)] = true
+				}
+			}
+		}
+	}
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "package %s\n", files[0].Name.Name)
+	needed := false
+	for _, name := range structs {
+		if hasDeepCopy[name] {
+			continue
+		}
+		needed = true
+		fmt.Fprintf(buf, "\nfunc (in *%s) DeepCopy() *%s { out := *in; return &out }\n", name, name)
+	}
+	if !needed {
+		return nil
+	}
+	return buf.Bytes()
+}
+
+func receiverName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if id, ok := expr.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package verify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crossplane-contrib/terraform-provider-gen/pkg/generator"
+)
+
+const typesFile = `package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Thing struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+	Name string
+}
+`
+
+func TestCheck(t *testing.T) {
+	decode := `package v1alpha1
+
+import (
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func DecodeThing(prev *Thing, ctyValue cty.Value) (resource.Managed, error) {
+	new := prev.DeepCopy()
+	new.Name = ctyValue.AsValueMap()["name"].AsString()
+	meta.SetExternalName(new, new.Name)
+	return new, nil
+}
+`
+	c := NewChecker()
+	err := c.Check("example.com/thing/v1alpha1", map[string][]byte{"types.go": []byte(typesFile), "decode.go": []byte(decode)})
+	if err != nil {
+		t.Errorf("Unexpected error type checking a valid package: %s", err)
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	decode := `package v1alpha1
+
+import (
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func DecodeThing_Name(p *ThingParameters, vals map[string]cty.Value) {
+	p.Name = vals["name"].AsString()
+}
+`
+	c := NewChecker()
+	err := c.Check("example.com/thing/v1alpha1", map[string][]byte{"types.go": []byte(typesFile), "decode.go": []byte(decode)})
+	me, ok := err.(generator.MultiError)
+	if !ok {
+		t.Fatalf("Expected a generator.MultiError, saw %v", err)
+	}
+	expected := []string{
+		`decode.go:9:26: undefined: ThingParameters`,
+		`decode.go:4:2: "fmt" imported and not used`,
+	}
+	if len(me.Errors()) != len(expected) {
+		t.Fatalf("Expected %d errors, saw:\n%s", len(expected), err)
+	}
+	for i, e := range me.Errors() {
+		if !strings.HasPrefix(e.Error(), expected[i]) {
+			t.Errorf("Expected error %q, saw %q", expected[i], e.Error())
+		}
+	}
+}